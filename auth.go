@@ -0,0 +1,211 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pranavbalakrishnan4100/beacon-mcp-server/config"
+	"github.com/pranavbalakrishnan4100/beacon-mcp-server/tools/anthropic"
+	"github.com/pranavbalakrishnan4100/beacon-mcp-server/tools/google/drive"
+	"github.com/pranavbalakrishnan4100/beacon-mcp-server/tools/slack"
+)
+
+const authUsage = `Usage:
+  beacon auth google login|status|logout [-account name]
+  beacon auth slack set|status [-workspace name]
+  beacon auth anthropic set|status
+`
+
+// runAuth implements the `beacon auth` subcommands, which set up credentials
+// out-of-band so the MCP server never has to prompt on its stdio session.
+func runAuth(args []string) int {
+	if len(args) < 2 {
+		fmt.Fprint(os.Stderr, authUsage)
+		return 2
+	}
+
+	var err error
+	switch service, action := args[0], args[1]; service {
+	case "google":
+		err = runGoogleAuth(action, args[2:])
+	case "slack":
+		err = runSlackAuth(action, args[2:])
+	case "anthropic":
+		err = runAnthropicAuth(action)
+	default:
+		fmt.Fprint(os.Stderr, authUsage)
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func runGoogleAuth(action string, args []string) error {
+	fs := flag.NewFlagSet("google "+action, flag.ContinueOnError)
+	account := fs.String("account", config.DefaultAccount, "Name of the Google account")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	g, err := cfg.GoogleAccount(*account)
+	if err != nil {
+		return err
+	}
+	client := drive.ClientFor(*account, g.Subject)
+
+	switch action {
+	case "login":
+		if err := client.Login(); err != nil {
+			return err
+		}
+		email, err := client.Email()
+		if err != nil {
+			return fmt.Errorf("logged in but unable to reach Google Drive: %w", err)
+		}
+		fmt.Printf("Google account %s: logged in as %s\n", *account, email)
+	case "status":
+		status := client.Status()
+		if !status.Authorized {
+			fmt.Printf("Google account %s (%s): not authorized: %v\n", *account, status.Mode, status.Err)
+			return nil
+		}
+		email, err := client.Email()
+		if err != nil {
+			return fmt.Errorf("token is valid but Google Drive rejected it: %w", err)
+		}
+		fmt.Printf("Google account %s (%s): authorized as %s, token expires %s\n", *account, status.Mode, email, status.Expiry.Format("2006-01-02 15:04:05"))
+	case "logout":
+		if err := client.Logout(); err != nil {
+			return err
+		}
+		fmt.Printf("Google account %s: logged out\n", *account)
+	default:
+		return fmt.Errorf("unknown action %q\n%s", action, authUsage)
+	}
+	return nil
+}
+
+func runSlackAuth(action string, args []string) error {
+	fs := flag.NewFlagSet("slack "+action, flag.ContinueOnError)
+	workspace := fs.String("workspace", config.DefaultAccount, "Name of the Slack workspace")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	s, err := cfg.SlackWorkspace(*workspace)
+	if err != nil {
+		return err
+	}
+
+	switch action {
+	case "set":
+		token, err := prompt("Slack user (xoxp-...) or bot (xoxb-...) token: ")
+		if err != nil {
+			return err
+		}
+		who, err := slack.ValidateToken(token)
+		if err != nil {
+			return fmt.Errorf("Slack rejected the token: %w", err)
+		}
+		err = updateCredentials(func(c *config.Credentials) {
+			if *workspace == config.DefaultAccount {
+				c.SlackToken = token
+				return
+			}
+			if c.SlackTokens == nil {
+				c.SlackTokens = map[string]string{}
+			}
+			c.SlackTokens[*workspace] = token
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Slack workspace %s: token saved for %s\n", *workspace, who)
+	case "status":
+		if s.Token == "" {
+			fmt.Printf("Slack workspace %s: no token configured\n", *workspace)
+			return nil
+		}
+		who, err := slack.ValidateToken(s.Token)
+		if err != nil {
+			fmt.Printf("Slack workspace %s: token is invalid: %v\n", *workspace, err)
+			return nil
+		}
+		fmt.Printf("Slack workspace %s: authorized as %s\n", *workspace, who)
+	default:
+		return fmt.Errorf("unknown action %q\n%s", action, authUsage)
+	}
+	return nil
+}
+
+func runAnthropicAuth(action string) error {
+	switch action {
+	case "set":
+		key, err := prompt("Anthropic API key (sk-ant-...): ")
+		if err != nil {
+			return err
+		}
+		if err := anthropic.ValidateAPIKey(key); err != nil {
+			return fmt.Errorf("Anthropic rejected the key: %w", err)
+		}
+		if err := updateCredentials(func(c *config.Credentials) { c.AnthropicAPIKey = key }); err != nil {
+			return err
+		}
+		fmt.Println("Anthropic API key saved")
+	case "status":
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+		if cfg.Anthropic.APIKey == "" {
+			fmt.Println("Anthropic: no API key configured")
+			return nil
+		}
+		if err := anthropic.ValidateAPIKey(cfg.Anthropic.APIKey); err != nil {
+			fmt.Printf("Anthropic: API key is invalid: %v\n", err)
+			return nil
+		}
+		fmt.Println("Anthropic: API key is valid")
+	default:
+		return fmt.Errorf("unknown action %q\n%s", action, authUsage)
+	}
+	return nil
+}
+
+// prompt reads a single secret line from stdin. Secrets are never accepted as
+// arguments so they stay out of shell history and process listings.
+func prompt(label string) (string, error) {
+	fmt.Fprint(os.Stderr, label)
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("unable to read input: %w", err)
+	}
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return "", fmt.Errorf("no value entered")
+	}
+	return line, nil
+}
+
+func updateCredentials(update func(*config.Credentials)) error {
+	creds, err := config.LoadCredentials()
+	if err != nil {
+		return err
+	}
+	update(creds)
+	return config.SaveCredentials(creds)
+}
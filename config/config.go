@@ -1 +1,361 @@
 package config
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultAccount names the account or workspace configured under the
+// top-level "google" or "slack" key.
+const DefaultAccount = "default"
+
+// Google auth modes supported by the drive package.
+const (
+	GoogleAuthOAuth           = "oauth"
+	GoogleAuthServiceAccount  = "service_account"
+	GoogleAuthExternalAccount = "external_account"
+)
+
+// Google holds the settings used to authenticate against Google APIs.
+type Google struct {
+	// AuthMode is one of "oauth" (default), "service_account" or "external_account".
+	AuthMode string `json:"auth_mode"`
+	// CredentialsFile is the OAuth client secret, service-account key or
+	// external-account (workload identity) JSON, depending on AuthMode.
+	CredentialsFile string `json:"credentials_file"`
+	// TokenFile is where the OAuth token is cached. Only used in "oauth" mode.
+	TokenFile string `json:"token_file"`
+	// Subject is the default user impersonated through domain-wide delegation.
+	// Only used in "service_account" mode; empty means no delegation.
+	Subject string `json:"subject"`
+	// AllowedSubjects lists the users a caller may impersonate by passing a
+	// subject to the Drive tools.
+	AllowedSubjects []string `json:"allowed_subjects"`
+}
+
+// Slack holds the settings used to talk to Slack.
+type Slack struct {
+	// Token is a Slack user (xoxp) or bot (xoxb) token. Falls back to SLACK_TOKEN and then to
+	// the token saved with `beacon auth slack set`.
+	Token string `json:"token"`
+	// TeamID scopes searches and channel listings to one workspace of an
+	// Enterprise Grid org when Token is an org-wide token.
+	TeamID string `json:"team_id"`
+	// SearchLimit is how many search matches a tool call returns by default.
+	SearchLimit int `json:"search_limit"`
+	// MaxSearchResults caps the matches a single tool call may ask for.
+	MaxSearchResults int `json:"max_search_results"`
+	// MaxThreads is how many of the best ranked conversations are handed to
+	// the model.
+	MaxThreads int `json:"max_threads"`
+	// ChannelWeights scales the relevance of conversations by channel name,
+	// e.g. {"announcements": 1.5, "random": 0.5}. Unlisted channels weigh 1.
+	ChannelWeights map[string]float64 `json:"channel_weights"`
+	// RecencyHalfLifeDays is the age at which a conversation's recency score
+	// has halved.
+	RecencyHalfLifeDays float64 `json:"recency_half_life_days"`
+	// MaxFileBytes caps how much of a file attached to a matched message is
+	// downloaded. Larger PDFs are skipped rather than cut.
+	MaxFileBytes int64 `json:"max_file_bytes"`
+	// MaxFileChars caps the text extracted from each attached file.
+	MaxFileChars int `json:"max_file_chars"`
+	// WriteChannels lists the channels, by name or ID, that the write tools
+	// may post and react in. The write tools are only offered when it is set.
+	WriteChannels []string `json:"write_channels"`
+	// IndexFile is the local message index kept up to date by `beacon
+	// listen` and searched next to search.messages. Defaults to
+	// beacon/slack-index.json in the user config dir, or
+	// beacon/slack-index-<name>.json for a named workspace.
+	IndexFile string `json:"index_file"`
+	// BackfillDays is how far back `beacon index` pages channel history
+	// into the index.
+	BackfillDays int `json:"backfill_days"`
+	// Events configures `beacon listen`.
+	Events SlackEvents `json:"events"`
+}
+
+// Slack event listener modes supported by `beacon listen`.
+const (
+	SlackEventsSocket = "socket"
+	SlackEventsHTTP   = "http"
+)
+
+// SlackEvents configures the listener that feeds Slack events into the local
+// message index.
+type SlackEvents struct {
+	// Mode is "socket" (Socket Mode, default) or "http" (Events API requests
+	// sent to ListenAddr).
+	Mode string `json:"mode"`
+	// AppToken is the app-level (xapp) token Socket Mode connects with.
+	// Falls back to SLACK_APP_TOKEN.
+	AppToken string `json:"app_token"`
+	// SigningSecret verifies Events API requests. Falls back to
+	// SLACK_SIGNING_SECRET.
+	SigningSecret string `json:"signing_secret"`
+	// ListenAddr is where the Events API endpoint listens in "http" mode.
+	ListenAddr string `json:"listen_addr"`
+}
+
+// Anthropic holds the settings used to talk to Claude.
+type Anthropic struct {
+	// APIKey falls back to ANTHROPIC_API_KEY and then to the key saved with
+	// `beacon auth anthropic set`.
+	APIKey string `json:"api_key"`
+}
+
+// Config is Beacon's runtime configuration.
+type Config struct {
+	Slack     Slack     `json:"slack"`
+	Anthropic Anthropic `json:"anthropic"`
+	Google    Google    `json:"google"`
+	// GoogleAccounts holds additional named Google accounts, e.g. a partner
+	// workspace next to the company one.
+	GoogleAccounts map[string]Google `json:"google_accounts"`
+	// SlackWorkspaces holds additional named Slack workspaces, e.g. the
+	// workspaces of an Enterprise Grid org or of an acquired company.
+	SlackWorkspaces map[string]Slack `json:"slack_workspaces"`
+}
+
+var (
+	configPath      string
+	credentialsPath string
+	credsFilePath   string
+	tokenPath       string
+
+	loadOnce sync.Once
+	loaded   *Config
+	loadErr  error
+)
+
+func init() {
+	flag.StringVar(&configPath, "config", os.Getenv("BEACON_CONFIG"), "Path to Beacon JSON config file")
+	flag.StringVar(&credentialsPath, "credentials-file", "", "Path to the credentials saved by `beacon auth` (default: user config dir)")
+	flag.StringVar(&credsFilePath, "creds-file-path", "", "Path to OAuth2 credentials file")
+	flag.StringVar(&tokenPath, "token-path", "", "Path to store token file")
+}
+
+// Load parses the command line flags and the optional config file once and
+// returns the resulting configuration. Flags take precedence over the file.
+func Load() (*Config, error) {
+	loadOnce.Do(func() {
+		if !flag.Parsed() {
+			flag.Parse()
+		}
+		loaded, loadErr = load()
+	})
+	return loaded, loadErr
+}
+
+func load() (*Config, error) {
+	cfg := &Config{}
+	if configPath != "" {
+		b, err := os.ReadFile(configPath)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read config file: %w", err)
+		}
+		if err := json.Unmarshal(b, cfg); err != nil {
+			return nil, fmt.Errorf("unable to parse config file: %w", err)
+		}
+	}
+
+	if credsFilePath != "" {
+		cfg.Google.CredentialsFile = credsFilePath
+	}
+	if tokenPath != "" {
+		cfg.Google.TokenFile = tokenPath
+	}
+	if cfg.Google.AuthMode == "" {
+		cfg.Google.AuthMode = GoogleAuthOAuth
+	}
+	if cfg.Slack.Token == "" {
+		cfg.Slack.Token = os.Getenv("SLACK_TOKEN")
+	}
+	if cfg.Slack.Events.AppToken == "" {
+		cfg.Slack.Events.AppToken = os.Getenv("SLACK_APP_TOKEN")
+	}
+	if cfg.Slack.Events.SigningSecret == "" {
+		cfg.Slack.Events.SigningSecret = os.Getenv("SLACK_SIGNING_SECRET")
+	}
+	setSlackDefaults(&cfg.Slack, "slack-index.json")
+	if cfg.Anthropic.APIKey == "" {
+		cfg.Anthropic.APIKey = os.Getenv("ANTHROPIC_API_KEY")
+	}
+	if cfg.Slack.Token == "" || cfg.Anthropic.APIKey == "" {
+		creds, err := LoadCredentials()
+		if err != nil {
+			return nil, err
+		}
+		if cfg.Slack.Token == "" {
+			cfg.Slack.Token = creds.SlackToken
+		}
+		if cfg.Anthropic.APIKey == "" {
+			cfg.Anthropic.APIKey = creds.AnthropicAPIKey
+		}
+	}
+
+	for name, s := range cfg.SlackWorkspaces {
+		if name == DefaultAccount {
+			return nil, fmt.Errorf("slack workspace name %q is reserved", DefaultAccount)
+		}
+		if s.Token == "" {
+			creds, err := LoadCredentials()
+			if err != nil {
+				return nil, err
+			}
+			s.Token = creds.SlackTokens[name]
+		}
+		setSlackDefaults(&s, "slack-index-"+name+".json")
+		cfg.SlackWorkspaces[name] = s
+	}
+
+	for name, g := range cfg.GoogleAccounts {
+		if name == DefaultAccount {
+			return nil, fmt.Errorf("google account name %q is reserved", DefaultAccount)
+		}
+		if g.AuthMode == "" {
+			g.AuthMode = GoogleAuthOAuth
+			cfg.GoogleAccounts[name] = g
+		}
+	}
+
+	return cfg, nil
+}
+
+// setSlackDefaults fills in the settings s leaves unset. indexFile names the
+// default message index in the user config dir.
+func setSlackDefaults(s *Slack, indexFile string) {
+	if s.SearchLimit <= 0 {
+		s.SearchLimit = 20
+	}
+	if s.MaxSearchResults <= 0 {
+		s.MaxSearchResults = 100
+	}
+	if s.SearchLimit > s.MaxSearchResults {
+		s.SearchLimit = s.MaxSearchResults
+	}
+	if s.MaxThreads <= 0 {
+		s.MaxThreads = 15
+	}
+	if s.RecencyHalfLifeDays <= 0 {
+		s.RecencyHalfLifeDays = 30
+	}
+	if s.MaxFileBytes <= 0 {
+		s.MaxFileBytes = 2 << 20
+	}
+	if s.MaxFileChars <= 0 {
+		s.MaxFileChars = 20000
+	}
+	if s.IndexFile == "" {
+		// Without a config dir there is just no index.
+		s.IndexFile, _ = userConfigFile(indexFile)
+	}
+	if s.BackfillDays <= 0 {
+		s.BackfillDays = 90
+	}
+	if s.Events.Mode == "" {
+		s.Events.Mode = SlackEventsSocket
+	}
+	if s.Events.ListenAddr == "" {
+		s.Events.ListenAddr = ":3000"
+	}
+}
+
+// SubjectAllowed reports whether subject may be impersonated on request.
+func (g Google) SubjectAllowed(subject string) bool {
+	if subject == g.Subject {
+		return true
+	}
+	for _, s := range g.AllowedSubjects {
+		if s == subject {
+			return true
+		}
+	}
+	return false
+}
+
+// BotToken reports whether Token is a bot (xoxb) token. Bots can't use
+// search.messages, so they search the local message index instead.
+func (s Slack) BotToken() bool {
+	return strings.HasPrefix(s.Token, "xoxb-")
+}
+
+// WriteAllowed reports whether the write tools may act in the channel with
+// the given ID and name. Names are matched as the channel is called now, so
+// IDs are the safer entries.
+func (s Slack) WriteAllowed(channelID, channelName string) bool {
+	for _, c := range s.WriteChannels {
+		c = strings.TrimPrefix(c, "#")
+		if c == channelID || (channelName != "" && c == channelName) {
+			return true
+		}
+	}
+	return false
+}
+
+// GoogleAccount returns the settings of the named Google account. An empty
+// name selects the default account.
+func (c *Config) GoogleAccount(name string) (Google, error) {
+	if name == "" || name == DefaultAccount {
+		return c.Google, nil
+	}
+	g, ok := c.GoogleAccounts[name]
+	if !ok {
+		return Google{}, fmt.Errorf("unknown google account %q", name)
+	}
+	return g, nil
+}
+
+// GoogleAccountNames lists the configured Google accounts, default first.
+func (c *Config) GoogleAccountNames() []string {
+	var names []string
+	for name := range c.GoogleAccounts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if c.Google.CredentialsFile != "" || len(names) == 0 {
+		names = append([]string{DefaultAccount}, names...)
+	}
+	return names
+}
+
+// SlackWorkspace returns the settings of the named Slack workspace. An empty
+// name selects the default workspace.
+func (c *Config) SlackWorkspace(name string) (Slack, error) {
+	if name == "" || name == DefaultAccount {
+		return c.Slack, nil
+	}
+	s, ok := c.SlackWorkspaces[name]
+	if !ok {
+		return Slack{}, fmt.Errorf("unknown slack workspace %q", name)
+	}
+	return s, nil
+}
+
+// SlackWorkspaceNames lists the configured Slack workspaces, default first.
+func (c *Config) SlackWorkspaceNames() []string {
+	var names []string
+	for name := range c.SlackWorkspaces {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if c.Slack.Token != "" || len(names) == 0 {
+		names = append([]string{DefaultAccount}, names...)
+	}
+	return names
+}
+
+// SlackWriteEnabled reports whether any Slack workspace allows the write
+// tools.
+func (c *Config) SlackWriteEnabled() bool {
+	for _, name := range c.SlackWorkspaceNames() {
+		if s, _ := c.SlackWorkspace(name); len(s.WriteChannels) > 0 {
+			return true
+		}
+	}
+	return false
+}
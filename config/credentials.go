@@ -0,0 +1,92 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Credentials are the secrets saved by the `beacon auth` subcommands. They are
+// kept apart from the config file so that the latter can be shared.
+type Credentials struct {
+	SlackToken      string `json:"slack_token,omitempty"`
+	AnthropicAPIKey string `json:"anthropic_api_key,omitempty"`
+	// SlackTokens holds the tokens of the named Slack workspaces.
+	SlackTokens map[string]string `json:"slack_tokens,omitempty"`
+}
+
+// CredentialsPath returns where credentials are saved: the -credentials-file
+// flag if set, otherwise beacon/credentials.json in the user config dir.
+func CredentialsPath() (string, error) {
+	if credentialsPath != "" {
+		return credentialsPath, nil
+	}
+	return userConfigFile("credentials.json")
+}
+
+// userConfigFile returns the path of name in Beacon's user config dir.
+func userConfigFile(name string) (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("unable to locate user config dir: %w", err)
+	}
+	return filepath.Join(dir, "beacon", name), nil
+}
+
+// LoadCredentials reads the saved credentials. A missing file yields empty
+// credentials.
+func LoadCredentials() (*Credentials, error) {
+	path, err := CredentialsPath()
+	if err != nil {
+		return nil, err
+	}
+	creds := &Credentials{}
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return creds, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read credentials file: %w", err)
+	}
+	if err := json.Unmarshal(b, creds); err != nil {
+		return nil, fmt.Errorf("unable to parse credentials file: %w", err)
+	}
+	return creds, nil
+}
+
+// SaveCredentials atomically replaces the credentials file with a copy
+// readable only by the current user.
+func SaveCredentials(creds *Credentials) error {
+	path, err := CredentialsPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("unable to create credentials dir: %w", err)
+	}
+
+	b, err := json.MarshalIndent(creds, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("unable to save credentials: %w", err)
+	}
+	tmp := f.Name()
+	defer os.Remove(tmp)
+
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return fmt.Errorf("unable to save credentials: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("unable to save credentials: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("unable to save credentials: %w", err)
+	}
+	return nil
+}
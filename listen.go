@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/pranavbalakrishnan4100/beacon-mcp-server/tools/slack"
+)
+
+// runIndex implements `beacon index`, which pages the history of the channels
+// the Slack token is a member of into the local message index, for one
+// workspace or all of them.
+func runIndex(args []string) int {
+	fs := flag.NewFlagSet("index", flag.ContinueOnError)
+	workspace := fs.String("workspace", "", "Name of the Slack workspace to index (default: all)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := slack.Backfill(ctx, *workspace); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// runListen implements `beacon listen`, which keeps the local message index
+// of one Slack workspace up to date from Slack events until interrupted.
+func runListen(args []string) int {
+	fs := flag.NewFlagSet("listen", flag.ContinueOnError)
+	workspace := fs.String("workspace", "", "Name of the Slack workspace to listen to (default: the first configured)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := slack.Listen(ctx, *workspace); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -1,22 +1,21 @@
 package main
 
 import (
+	"flag"
+	"fmt"
 	"log"
 	"os"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
+	"github.com/pranavbalakrishnan4100/beacon-mcp-server/config"
+	"github.com/pranavbalakrishnan4100/beacon-mcp-server/tools/experts"
 	"github.com/pranavbalakrishnan4100/beacon-mcp-server/tools/google/drive"
 	"github.com/pranavbalakrishnan4100/beacon-mcp-server/tools/slack"
 )
 
 var Serv *server.MCPServer
 
-func init() {
-	//fmt.Printf("INIT")
-	drive.Authorize()
-}
-
 func main() {
 
 	// Setup log file
@@ -26,6 +25,31 @@ func main() {
 	// }
 	log.SetOutput(os.Stderr)
 
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	if args := flag.Args(); len(args) > 0 {
+		switch args[0] {
+		case "auth":
+			os.Exit(runAuth(args[1:]))
+		case "listen":
+			os.Exit(runListen(args[1:]))
+		case "index":
+			os.Exit(runIndex(args[1:]))
+		}
+	}
+
+	// Never authorize interactively here: anything that prompts would corrupt
+	// the stdio session. Just point at `beacon auth` when something is missing.
+	for _, account := range cfg.GoogleAccountNames() {
+		g, _ := cfg.GoogleAccount(account)
+		if status := drive.ClientFor(account, g.Subject).Status(); !status.Authorized {
+			log.Printf("Google account %s is not authorized (%v), run `beacon auth google login -account %s`", account, status.Err, account)
+		}
+	}
+
 	Serv = server.NewMCPServer(
 		"Beacon MCP",
 		"1.0.0",
@@ -35,14 +59,34 @@ func main() {
 	)
 
 	addTools()
+	if cfg.SlackWriteEnabled() {
+		addSlackWriteTools()
+	}
 
-	err := server.ServeStdio(Serv)
+	err = server.ServeStdio(Serv)
 	if err != nil {
 		//fmt.Printf("Server error: %v\n", err)
 	}
 
 }
 
+// Slack tools take an optional workspace. Searches cover every configured
+// workspace without one; tools about a channel or a link need a single one.
+var (
+	slackWorkspace = mcp.WithString("workspace",
+		mcp.Description("Optional name of the Slack workspace the channel is in. Defaults to the default workspace; required when none is configured."),
+	)
+	slackLinkWorkspace = mcp.WithString("workspace",
+		mcp.Description("Optional name of the Slack workspace the link points into. Defaults to the workspace the link's address belongs to; required when it can't be told from the link."),
+	)
+)
+
+func slackWorkspaces(action string) mcp.ToolOption {
+	return mcp.WithString("workspace",
+		mcp.Description(fmt.Sprintf("Optional name of the Slack workspace to %s. Leave empty to %s all configured workspaces.", action, action)),
+	)
+}
+
 func addTools() {
 	slackMessagesTool := mcp.NewTool("getMessagesFromSlack",
 		mcp.WithDescription("Get all relevant Slack messages regarding the user's query."),
@@ -50,6 +94,42 @@ func addTools() {
 			mcp.Required(),
 			mcp.Description("The specific topic the user is looking to know about, without changing the terminology. "),
 		),
+		mcp.WithNumber("limit",
+			mcp.Description("Optional number of matching messages to fetch. Use a larger value for busy topics."),
+			mcp.Min(1),
+		),
+		mcp.WithNumber("page",
+			mcp.Description("Optional page of results to fetch, starting at 1. Use the page suggested by a previous call to see more matches."),
+			mcp.Min(1),
+		),
+		mcp.WithArray("channels",
+			mcp.Description("Optional channel names to search in, without the leading #."),
+			mcp.Items(map[string]interface{}{"type": "string"}),
+		),
+		mcp.WithArray("authors",
+			mcp.Description("Optional Slack user names whose messages to search, without the leading @."),
+			mcp.Items(map[string]interface{}{"type": "string"}),
+		),
+		mcp.WithString("after",
+			mcp.Description("Optional date (YYYY-MM-DD); only messages after it are searched."),
+		),
+		mcp.WithString("before",
+			mcp.Description("Optional date (YYYY-MM-DD); only messages before it are searched."),
+		),
+		mcp.WithBoolean("has_link",
+			mcp.Description("Only return messages containing a link."),
+		),
+		mcp.WithBoolean("has_attachment",
+			mcp.Description("Only return messages with an attached file."),
+		),
+		mcp.WithBoolean("thread_only",
+			mcp.Description("Only return messages that are part of a thread."),
+		),
+		mcp.WithString("sort",
+			mcp.Description("Order of matches: by relevance (score) or newest first (timestamp)."),
+			mcp.Enum("score", "timestamp"),
+		),
+		slackWorkspaces("search"),
 	)
 	Serv.AddTool(slackMessagesTool, slack.GetMessagesFromSlack)
 
@@ -63,10 +143,160 @@ func addTools() {
 			mcp.Required(),
 			mcp.Description("The query entered by the user as it is without any changes."),
 		),
+		mcp.WithString("subject",
+			mcp.Description("Optional email of the user to search Drive as, when the server uses domain-wide delegation."),
+		),
+		mcp.WithString("account",
+			mcp.Description("Optional name of the Google account to search. Leave empty to search all configured accounts."),
+		),
 	)
 	Serv.AddTool(googleDriveTool, drive.GetFilesFromDrive)
 
-	// slackChannelsTool := mcp.NewTool("getChannelsFromSlack",
-	// 	mcp.WithDescription("Get the list of slack channels available to the user."))
-	// Serv.AddTool(slackChannelsTool, slack.GetMessagesFromSlack)
+	slackChannelsTool := mcp.NewTool("getChannelsFromSlack",
+		mcp.WithDescription("Get the list of slack channels available to the user, with their topic, purpose and member count."),
+		mcp.WithArray("types",
+			mcp.Description("Optional kinds of conversations to list: public, private, mpim (group DMs) and im (DMs). Defaults to public and private channels."),
+			mcp.Items(map[string]interface{}{"type": "string", "enum": []string{"public", "private", "mpim", "im"}}),
+		),
+		mcp.WithBoolean("member_only",
+			mcp.Description("Only list channels the user is a member of."),
+		),
+		mcp.WithBoolean("exclude_archived",
+			mcp.Description("Leave out archived channels. Defaults to true."),
+		),
+		mcp.WithString("name_prefix",
+			mcp.Description("Optional prefix the channel names must start with, e.g. \"team-\"."),
+		),
+		slackWorkspaces("list the channels of"),
+	)
+	Serv.AddTool(slackChannelsTool, slack.GetChannelsFromSlack)
+
+	slackDigestTool := mcp.NewTool("getChannelDigestFromSlack",
+		mcp.WithDescription("Get everything posted in a Slack channel over a time range, grouped by thread, with key decisions, open questions and links. Use this for questions like \"what happened in #incidents this week\"."),
+		mcp.WithString("channel",
+			mcp.Required(),
+			mcp.Description("The channel name (with or without #) or ID."),
+		),
+		mcp.WithString("since",
+			mcp.Description("Optional start of the range, as YYYY-MM-DD or an RFC 3339 time. Defaults to 7 days ago."),
+		),
+		mcp.WithString("until",
+			mcp.Description("Optional end of the range, as YYYY-MM-DD (including that day) or an RFC 3339 time. Defaults to now."),
+		),
+		slackWorkspace,
+	)
+	Serv.AddTool(slackDigestTool, slack.GetChannelDigestFromSlack)
+
+	slackThreadTool := mcp.NewTool("getThreadFromSlack",
+		mcp.WithDescription("Get a full Slack thread from a permalink the user pasted."),
+		mcp.WithString("permalink",
+			mcp.Required(),
+			mcp.Description("The Slack link to a message or thread, as pasted by the user."),
+		),
+		slackLinkWorkspace,
+	)
+	Serv.AddTool(slackThreadTool, slack.GetThreadFromSlack)
+
+	slackChannelContextTool := mcp.NewTool("getChannelContextFromSlack",
+		mcp.WithDescription("Get the pinned messages, bookmarks and canvas of a Slack channel: the knowledge its members curated. Use this for questions like \"where are the runbooks for #payments\"."),
+		mcp.WithString("channel",
+			mcp.Required(),
+			mcp.Description("The channel name (with or without #) or ID."),
+		),
+		slackWorkspace,
+	)
+	Serv.AddTool(slackChannelContextTool, slack.GetChannelContextFromSlack)
+
+	expertsTool := mcp.NewTool("getExpertsForTopic",
+		mcp.WithDescription("Find the people who know most about a topic, from who wrote about it in Slack and who owns or edits Drive files about it, with their title, timezone and status. Use this for questions like \"who should I ask about X\"."),
+		mcp.WithString("topic",
+			mcp.Required(),
+			mcp.Description("The specific topic the user is looking to know about, without changing the terminology. "),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("Optional number of people to return. Defaults to 10."),
+			mcp.Min(1),
+		),
+		slackWorkspaces("search"),
+	)
+	Serv.AddTool(expertsTool, experts.GetExpertsForTopic)
+}
+
+// addSlackWriteTools registers the tools that post to Slack. They are opt-in
+// through slack.write_channels and only act in the channels listed there.
+func addSlackWriteTools() {
+	confirm := mcp.WithBoolean("confirm",
+		mcp.Description("Set to true only after the user has seen and approved exactly what will be sent, together with preview_token. Without it the tool only returns a preview."),
+	)
+	previewToken := mcp.WithString("preview_token",
+		mcp.Description("The token of the preview the user approved, required with confirm."),
+	)
+
+	postTool := mcp.NewTool("postMessageToSlack",
+		mcp.WithDescription("Post a message in a Slack channel. Always show the user the message and get their approval first."),
+		mcp.WithString("channel",
+			mcp.Required(),
+			mcp.Description("The channel name (with or without #) or ID."),
+		),
+		mcp.WithString("text",
+			mcp.Required(),
+			mcp.Description("The message, in Slack mrkdwn."),
+		),
+		slackWorkspace,
+		confirm,
+		previewToken,
+	)
+	Serv.AddTool(postTool, slack.PostMessageToSlack)
+
+	replyTool := mcp.NewTool("replyInSlackThread",
+		mcp.WithDescription("Reply in the Slack thread of a message, e.g. to post a summary back to the thread that was researched. Always show the user the reply and get their approval first."),
+		mcp.WithString("permalink",
+			mcp.Required(),
+			mcp.Description("The Slack link to the thread or to any message in it."),
+		),
+		mcp.WithString("text",
+			mcp.Required(),
+			mcp.Description("The reply, in Slack mrkdwn."),
+		),
+		slackLinkWorkspace,
+		confirm,
+		previewToken,
+	)
+	Serv.AddTool(replyTool, slack.ReplyInSlackThread)
+
+	reactTool := mcp.NewTool("addReactionInSlack",
+		mcp.WithDescription("Add an emoji reaction to a Slack message. Always get the user's approval first."),
+		mcp.WithString("permalink",
+			mcp.Required(),
+			mcp.Description("The Slack link to the message."),
+		),
+		mcp.WithString("emoji",
+			mcp.Required(),
+			mcp.Description("The emoji name, e.g. \"white_check_mark\", with or without colons."),
+		),
+		slackLinkWorkspace,
+		confirm,
+		previewToken,
+	)
+	Serv.AddTool(reactTool, slack.AddReactionInSlack)
+
+	scheduleTool := mcp.NewTool("scheduleMessageInSlack",
+		mcp.WithDescription("Schedule a message to be posted in a Slack channel later. Always show the user the message and time and get their approval first."),
+		mcp.WithString("channel",
+			mcp.Required(),
+			mcp.Description("The channel name (with or without #) or ID."),
+		),
+		mcp.WithString("text",
+			mcp.Required(),
+			mcp.Description("The message, in Slack mrkdwn."),
+		),
+		mcp.WithString("post_at",
+			mcp.Required(),
+			mcp.Description("When to post, as an RFC 3339 time with a timezone offset, at most 120 days ahead."),
+		),
+		slackWorkspace,
+		confirm,
+		previewToken,
+	)
+	Serv.AddTool(scheduleTool, slack.ScheduleMessageInSlack)
 }
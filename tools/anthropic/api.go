@@ -2,12 +2,14 @@ package anthropic
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
 	"strings"
 	"unicode"
 
 	anthropic "github.com/anthropics/anthropic-sdk-go"
 	"github.com/anthropics/anthropic-sdk-go/option"
+	"github.com/pranavbalakrishnan4100/beacon-mcp-server/config"
 	"github.com/slack-go/slack"
 )
 
@@ -112,11 +114,24 @@ func parseTopics(response string) []string {
 	return topics
 }
 
+// newClient returns a client for the configured API key.
+func newClient() (anthropic.Client, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return anthropic.Client{}, err
+	}
+	if cfg.Anthropic.APIKey == "" {
+		return anthropic.Client{}, fmt.Errorf("no Anthropic API key configured, run `beacon auth anthropic set`")
+	}
+	return anthropic.NewClient(option.WithAPIKey(cfg.Anthropic.APIKey)), nil
+}
+
 func SendMessageToClaude(prompt string) (message *anthropic.Message, err error) {
 
-	client := anthropic.NewClient(
-		option.WithAPIKey("sk-ant-REDACTED"),
-	)
+	client, err := newClient()
+	if err != nil {
+		return nil, err
+	}
 
 	message, err = client.Messages.New(context.TODO(), anthropic.MessageNewParams{
 		MaxTokens: 2048,
@@ -132,6 +147,44 @@ func SendMessageToClaude(prompt string) (message *anthropic.Message, err error)
 	return
 }
 
+// ExtractPDFText has Claude transcribe the text of a PDF, which Beacon can't
+// parse itself.
+func ExtractPDFText(ctx context.Context, pdf []byte) (string, error) {
+	client, err := newClient()
+	if err != nil {
+		return "", err
+	}
+
+	message, err := client.Messages.New(ctx, anthropic.MessageNewParams{
+		MaxTokens: 4096,
+		Messages: []anthropic.MessageParam{
+			anthropic.NewUserMessage(
+				anthropic.ContentBlockParamOfRequestDocumentBlock(anthropic.Base64PDFSourceParam{
+					Data: base64.StdEncoding.EncodeToString(pdf),
+				}),
+				anthropic.NewTextBlock("Transcribe the text of this document as plain text, keeping its headings and lists. Reply with the text only."),
+			),
+		},
+		Model: anthropic.ModelClaude3_7SonnetLatest,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var text strings.Builder
+	for _, block := range message.Content {
+		text.WriteString(block.Text)
+	}
+	return strings.TrimSpace(text.String()), nil
+}
+
+// ValidateAPIKey checks key against the Anthropic API.
+func ValidateAPIKey(key string) error {
+	client := anthropic.NewClient(option.WithAPIKey(key))
+	_, err := client.Models.List(context.Background(), anthropic.ModelListParams{Limit: anthropic.Int(1)})
+	return err
+}
+
 func sanitizeSlackText(input string) string {
 	var output []rune
 	for _, r := range input {
@@ -0,0 +1,189 @@
+// Package experts finds the people who know about a topic by combining what
+// the Slack and Drive tools know about them.
+package experts
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/pranavbalakrishnan4100/beacon-mcp-server/tools/google/drive"
+	"github.com/pranavbalakrishnan4100/beacon-mcp-server/tools/slack"
+)
+
+const (
+	// defaultExperts is how many people are returned by default.
+	defaultExperts = 10
+	// maxDriveLinks bounds the Drive files linked per person, next to their
+	// Slack messages.
+	maxDriveLinks = 3
+	// Drive files weigh like a well ranked Slack message when owned, and half
+	// that when the person only made the last change.
+	driveOwnerWeight    = 1.0
+	driveModifierWeight = 0.5
+)
+
+// Person is someone who has written about a topic in Slack or keeps Drive
+// files about it.
+type Person struct {
+	slack.Profile
+	Messages      int      `json:"slack_messages"`
+	DriveOwned    int      `json:"drive_files_owned"`
+	DriveModified int      `json:"drive_files_modified"`
+	Score         float64  `json:"score"`
+	Links         []string `json:"links,omitempty"`
+}
+
+func GetExpertsForTopic(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	topic, _ := request.Params.Arguments["topic"].(string)
+	if strings.TrimSpace(topic) == "" {
+		return mcp.NewToolResultError("A topic is required."), nil
+	}
+	limit := defaultExperts
+	if v, ok := request.Params.Arguments["limit"].(float64); ok {
+		limit = max(1, int(v))
+	}
+
+	slackContributors, errResult := slack.TopicContributors(ctx, request, topic)
+	if errResult != nil {
+		return errResult, nil
+	}
+
+	people := map[string]*Person{}
+	var order []string
+	person := func(key string) *Person {
+		p, ok := people[key]
+		if !ok {
+			p = &Person{}
+			people[key] = p
+			order = append(order, key)
+		}
+		return p
+	}
+
+	// People are matched across Slack and Drive by their email.
+	for _, c := range slackContributors {
+		key := c.SlackID
+		if c.Email != "" {
+			key = strings.ToLower(c.Email)
+		}
+		p := person(key)
+		p.Profile = c.Profile
+		p.Messages += c.Messages
+		p.Score += c.Score
+		p.Links = append(p.Links, c.Links...)
+	}
+
+	driveContributors, driveErr := drive.TopicContributors(topic)
+	if driveErr != nil {
+		log.Printf("Unable to look up Drive contributors: %v", driveErr)
+	}
+	for _, c := range driveContributors {
+		p := person(strings.ToLower(c.Email))
+		if p.Name == "" {
+			p.Name, p.Email = c.Name, c.Email
+		}
+		p.DriveOwned += c.Owned
+		p.DriveModified += c.Modified
+		p.Score += driveOwnerWeight*float64(c.Owned) + driveModifierWeight*float64(c.Modified)
+		for i, link := range c.Files {
+			if i == maxDriveLinks {
+				break
+			}
+			p.Links = append(p.Links, link)
+		}
+	}
+
+	ranked := make([]Person, 0, len(order))
+	for _, key := range order {
+		p := people[key]
+		p.Score = math.Round(p.Score*1000) / 1000
+		ranked = append(ranked, *p)
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].Score > ranked[j].Score
+	})
+	if len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+
+	// People only found in Drive get their Slack profile if they have one.
+	for i := range ranked {
+		p := &ranked[i]
+		if p.SlackID != "" || p.Email == "" {
+			continue
+		}
+		if profile, ok := slack.ProfileByEmail(ctx, request, p.Email); ok {
+			email := p.Email
+			p.Profile = profile
+			if p.Email == "" {
+				p.Email = email
+			}
+		}
+	}
+
+	if len(ranked) == 0 {
+		return mcp.NewToolResultText("Nobody was found who has written about this topic in Slack or keeps Drive files about it."), nil
+	}
+	responseText := fmt.Sprintf("These are the people who seem to know most about %q, best first, based on their Slack messages and the Drive files they own or edit. Suggest who to ask and why.\n\n%s", topic, renderPeople(ranked))
+	if driveErr != nil {
+		responseText += "\nGoogle Drive could not be searched, so only Slack was taken into account."
+	}
+
+	result := mcp.NewToolResultText(responseText)
+	data, err := json.Marshal(struct {
+		People []Person `json:"people"`
+	}{ranked})
+	if err != nil {
+		return result, nil
+	}
+	result.Content = append(result.Content, mcp.NewEmbeddedResource(mcp.TextResourceContents{
+		URI:      "slack://experts?topic=" + url.QueryEscape(topic),
+		MIMEType: "application/json",
+		Text:     string(data),
+	}))
+	return result, nil
+}
+
+func renderPeople(people []Person) string {
+	var b strings.Builder
+	for i, p := range people {
+		fmt.Fprintf(&b, "%d. **%s**", i+1, p.Name)
+		var details []string
+		if p.RealName != "" && p.RealName != p.Name {
+			details = append(details, p.RealName)
+		}
+		for _, d := range []string{p.Title, p.Email, p.Timezone} {
+			if d != "" {
+				details = append(details, d)
+			}
+		}
+		if p.Status != "" {
+			details = append(details, "status: "+p.Status)
+		}
+		if len(details) > 0 {
+			fmt.Fprintf(&b, " (%s)", strings.Join(details, ", "))
+		}
+		var activity []string
+		if p.Messages > 0 {
+			activity = append(activity, fmt.Sprintf("%d matching Slack messages", p.Messages))
+		}
+		if p.DriveOwned > 0 {
+			activity = append(activity, fmt.Sprintf("owns %d Drive files", p.DriveOwned))
+		}
+		if p.DriveModified > 0 {
+			activity = append(activity, fmt.Sprintf("last edited %d Drive files", p.DriveModified))
+		}
+		fmt.Fprintf(&b, ": %s\n", strings.Join(activity, ", "))
+		for _, link := range p.Links {
+			fmt.Fprintf(&b, "   - %s\n", link)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,129 @@
+// Package extract turns downloaded files into plain text for the model,
+// bounding how much is read and how much is returned.
+package extract
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pranavbalakrishnan4100/beacon-mcp-server/tools/anthropic"
+	"golang.org/x/net/html"
+)
+
+// ErrUnsupported is returned for files whose text can't be extracted, such as
+// images and archives.
+var ErrUnsupported = errors.New("unsupported file type")
+
+// textTypes are the non-text/* mime types that are plain text anyway.
+var textTypes = map[string]bool{
+	"application/json":       true,
+	"application/xml":        true,
+	"application/javascript": true,
+	"application/x-yaml":     true,
+	"application/x-sh":       true,
+	"application/sql":        true,
+}
+
+// Buffer is an io.Writer that keeps the first Max bytes written to it and
+// fails once more arrive, so a download can be cut short.
+type Buffer struct {
+	Max       int64
+	Truncated bool
+	buf       bytes.Buffer
+}
+
+// errFull stops the copy into a full Buffer.
+var errFull = errors.New("file exceeds size limit")
+
+func (b *Buffer) Write(p []byte) (int, error) {
+	room := b.Max - int64(b.buf.Len())
+	if int64(len(p)) > room {
+		b.buf.Write(p[:max(room, 0)])
+		b.Truncated = true
+		return int(max(room, 0)), errFull
+	}
+	return b.buf.Write(p)
+}
+
+// Bytes returns what was kept.
+func (b *Buffer) Bytes() []byte {
+	return b.buf.Bytes()
+}
+
+// IsFull reports whether err only means the Buffer stopped a download at its
+// size limit.
+func IsFull(err error) bool {
+	return errors.Is(err, errFull)
+}
+
+// Supported reports whether Text can handle files of mimeType.
+func Supported(mimeType string) bool {
+	mimeType = baseType(mimeType)
+	return strings.HasPrefix(mimeType, "text/") || textTypes[mimeType] || mimeType == "application/pdf"
+}
+
+// Text extracts the text of a file of the given mime type and cuts it to
+// maxChars runes. PDFs are transcribed by Claude, so they must not have been
+// truncated. The second result reports whether the text was cut.
+func Text(ctx context.Context, data []byte, mimeType string, maxChars int) (string, bool, error) {
+	var text string
+	switch mimeType = baseType(mimeType); {
+	case mimeType == "text/html":
+		text = htmlText(data)
+	case strings.HasPrefix(mimeType, "text/") || textTypes[mimeType]:
+		text = strings.ToValidUTF8(string(data), "")
+	case mimeType == "application/pdf":
+		var err error
+		if text, err = anthropic.ExtractPDFText(ctx, data); err != nil {
+			return "", false, fmt.Errorf("unable to extract PDF text: %w", err)
+		}
+	default:
+		return "", false, ErrUnsupported
+	}
+
+	text = strings.TrimSpace(text)
+	if utf8.RuneCountInString(text) <= maxChars {
+		return text, false, nil
+	}
+	return string([]rune(text)[:maxChars]), true, nil
+}
+
+func baseType(mimeType string) string {
+	t, _, _ := strings.Cut(mimeType, ";")
+	return strings.ToLower(strings.TrimSpace(t))
+}
+
+// htmlText returns the visible text of an HTML document, one block element
+// per line.
+func htmlText(data []byte) string {
+	var b strings.Builder
+	z := html.NewTokenizer(bytes.NewReader(data))
+	skip := 0
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return b.String()
+		case html.StartTagToken:
+			name, _ := z.TagName()
+			switch string(name) {
+			case "script", "style":
+				skip++
+			case "p", "div", "br", "li", "tr", "h1", "h2", "h3", "h4", "h5", "h6":
+				b.WriteString("\n")
+			}
+		case html.EndTagToken:
+			if name, _ := z.TagName(); string(name) == "script" || string(name) == "style" {
+				skip = max(skip-1, 0)
+			}
+		case html.TextToken:
+			if skip == 0 {
+				b.WriteString(strings.Join(strings.Fields(string(z.Text())), " "))
+				b.WriteString(" ")
+			}
+		}
+	}
+}
@@ -0,0 +1,3 @@
+// Package filelock serializes writers of files shared by several Beacon
+// processes, such as the OAuth token and the Slack message index.
+package filelock
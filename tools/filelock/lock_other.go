@@ -0,0 +1,14 @@
+//go:build !unix
+
+package filelock
+
+import "sync"
+
+var mu sync.Mutex
+
+// Lock only serializes writers within this process on platforms without
+// flock.
+func Lock(path string) (func(), error) {
+	mu.Lock()
+	return mu.Unlock, nil
+}
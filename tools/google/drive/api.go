@@ -8,23 +8,117 @@ import (
 	"os"
 
 	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/pranavbalakrishnan4100/beacon-mcp-server/config"
 	"github.com/pranavbalakrishnan4100/beacon-mcp-server/tools/anthropic"
+	"google.golang.org/api/drive/v3"
 )
 
+type FileSummary struct {
+	Account string
+	Name    string
+	ID      string
+	Link    string
+	Answer  string
+}
+
+var supportedMimeTypes = map[string]bool{
+	"application/vnd.google-apps.document": true,
+	"text/plain":                           true,
+	"application/pdf":                      true,
+}
+
 func GetFilesFromDrive(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	log.SetOutput(os.Stderr)
 
 	topic := request.Params.Arguments["topic"].(string)
 	query := request.Params.Arguments["query"].(string)
+	subject, _ := request.Params.Arguments["subject"].(string)
+	account, _ := request.Params.Arguments["account"].(string)
 	responseText := ""
-	driveSrv, err := Authorize()
+
+	cfg, err := config.Load()
 	if err != nil {
-		log.Printf("Failed to authorize: %v", err)
+		log.Printf("Failed to load config: %v", err)
 		return mcp.NewToolResultText("Unable to authorize and connect to Google."), nil
 	}
 
+	accounts := cfg.GoogleAccountNames()
+	if account != "" {
+		if _, err := cfg.GoogleAccount(account); err != nil {
+			return mcp.NewToolResultText(fmt.Sprintf("Unknown Google account %q. Available accounts: %v", account, accounts)), nil
+		}
+		accounts = []string{account}
+	}
+
+	var summaries []FileSummary
+	fileIds := []string{}
+	var failures []string
+
+	for _, name := range accounts {
+		accountSummaries, accountFileIds, err := searchAccount(cfg, name, subject, topic, query)
+		if err != nil {
+			log.Printf("Google account %s: %v", name, err)
+			// A single account keeps the previous behaviour of reporting the
+			// failure directly; when searching all accounts the others still
+			// get a chance to answer.
+			if len(accounts) == 1 {
+				return mcp.NewToolResultText(describeError(err)), nil
+			}
+			failures = append(failures, fmt.Sprintf("%s: %s", name, describeError(err)))
+			continue
+		}
+		summaries = append(summaries, accountSummaries...)
+		fileIds = append(fileIds, accountFileIds...)
+	}
+
+	if len(summaries) == 0 {
+		if len(fileIds) == 0 {
+			if len(failures) > 0 {
+				return mcp.NewToolResultText(fmt.Sprintf("No matching files were found in the Google Drive.\n\nSome accounts could not be searched:\n%v", failures)), nil
+			}
+			return mcp.NewToolResultText("No matching files were found in the Google Drive."), nil
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("Files were found but no readable content could be extracted or analyzed.\n\nFile types:\n%v\n\nTotal Files: %d", fileIds, len(fileIds))), nil
+	}
+
+	responseText = "Please explain as though you are the source of information, do not have to mention where you obtained teh information from or anything. Imagine yourself as a member of the team with all the knowledge present in you. Now, here are the most relevant file summaries based on your query:\n\n"
+	for _, s := range summaries {
+		responseText += fmt.Sprintf("📄 *%s* (ID: `%s`, account: %s)\n🔗 %s\n🧠 %s\n\n", s.Name, s.ID, s.Account, s.Link, s.Answer)
+	}
+	if len(failures) > 0 {
+		responseText += fmt.Sprintf("Some accounts could not be searched:\n%v\n", failures)
+	}
+
+	return mcp.NewToolResultText(responseText), nil
+}
+
+// searchAccount looks for files about topic in one Google account and
+// summarizes the readable ones with respect to query. It also returns the mime
+// types of every supported file found, for reporting when none could be read.
+func searchAccount(cfg *config.Config, account, subject, topic, query string) ([]FileSummary, []string, error) {
+	g, err := cfg.GoogleAccount(account)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch {
+	case g.AuthMode != config.GoogleAuthServiceAccount:
+		// Only service accounts impersonate users; the others search as
+		// themselves.
+		subject = ""
+	case subject == "":
+		subject = g.Subject
+	case !g.SubjectAllowed(subject):
+		return nil, nil, fmt.Errorf("%w: %s", errSubjectNotAllowed, subject)
+	}
+
+	driveSrv, err := ClientFor(account, subject).Service()
+	if err != nil {
+		log.Printf("Failed to authorize: %v", err)
+		return nil, nil, fmt.Errorf("%w: %w", errAuthorize, err)
+	}
+
 	files, err := driveSrv.Files.List().
-		Q(fmt.Sprintf("fullText contains '%s' or name contains '%s'", topic, topic)).
+		Q(topicQuery(topic)).
 		IncludeItemsFromAllDrives(true).
 		SupportsAllDrives(true).
 		Fields("files(id, name, mimeType, webViewLink)").
@@ -32,24 +126,7 @@ func GetFilesFromDrive(ctx context.Context, request mcp.CallToolRequest) (*mcp.C
 		Do()
 	if err != nil {
 		log.Printf("Unable to retrieve files: %v", err)
-		return mcp.NewToolResultText(fmt.Sprintf("Unable to retrieve files: %v", err)), nil
-	}
-
-	if len(files.Files) == 0 {
-		return mcp.NewToolResultText("No matching files were found in the Google Drive."), nil
-	}
-
-	supportedMimeTypes := map[string]bool{
-		"application/vnd.google-apps.document": true,
-		"text/plain":                           true,
-		"application/pdf":                      true,
-	}
-
-	type FileSummary struct {
-		Name   string
-		ID     string
-		Link   string
-		Answer string
+		return nil, nil, fmt.Errorf("unable to retrieve files: %w", err)
 	}
 
 	var summaries []FileSummary
@@ -64,33 +141,9 @@ func GetFilesFromDrive(ctx context.Context, request mcp.CallToolRequest) (*mcp.C
 
 		fileIds = append(fileIds, file.MimeType)
 
-		var content []byte
-		var readErr error
-
-		if file.MimeType == "application/vnd.google-apps.document" {
-			// Export Google Docs as plain text
-			resp, err := driveSrv.Files.Export(file.Id, "text/plain").Download()
-			if err != nil || resp == nil || resp.Body == nil {
-				log.Printf("Failed to export Google Doc: %v", err)
-				return mcp.NewToolResultText(fmt.Sprintf("Failed to export Google Doc: %v", err)), nil
-				//continue
-			}
-			content, readErr = io.ReadAll(resp.Body)
-			resp.Body.Close()
-		} else {
-			resp, err := driveSrv.Files.Get(file.Id).Download()
-			if err != nil || resp == nil || resp.Body == nil {
-				log.Printf("Failed to download file: %v", err)
-				return mcp.NewToolResultText(fmt.Sprintf("Failed to download file: %v", err)), nil
-				//continue
-			}
-			content, readErr = io.ReadAll(resp.Body)
-			resp.Body.Close()
-		}
-
-		if readErr != nil {
-			return mcp.NewToolResultText(fmt.Sprintf("Error reading file content: %v", readErr)), nil
-			//continue
+		content, err := downloadFile(driveSrv, file)
+		if err != nil {
+			return nil, nil, err
 		}
 
 		answer := ""
@@ -105,22 +158,49 @@ func GetFilesFromDrive(ctx context.Context, request mcp.CallToolRequest) (*mcp.C
 		}
 
 		summaries = append(summaries, FileSummary{
-			Name:   file.Name,
-			ID:     file.Id,
-			Link:   file.WebViewLink,
-			Answer: string(answer),
+			Account: account,
+			Name:    file.Name,
+			ID:      file.Id,
+			Link:    file.WebViewLink,
+			Answer:  string(answer),
 		})
 
 	}
 
-	if len(summaries) == 0 {
-		return mcp.NewToolResultText(fmt.Sprintf("Files were found but no readable content could be extracted or analyzed.\n\nFile types:\n%v\n\nTotal Files: %d", fileIds, len(fileIds))), nil
-	}
+	return summaries, fileIds, nil
+}
 
-	responseText = "Please explain as though you are the source of information, do not have to mention where you obtained teh information from or anything. Imagine yourself as a member of the team with all the knowledge present in you. Now, here are the most relevant file summaries based on your query:\n\n"
-	for _, s := range summaries {
-		responseText += fmt.Sprintf("📄 *%s* (ID: `%s`)\n🔗 %s\n🧠 %s\n\n", s.Name, s.ID, s.Link, s.Answer)
+func downloadFile(driveSrv *drive.Service, file *drive.File) ([]byte, error) {
+	var content []byte
+	var readErr error
+
+	if file.MimeType == "application/vnd.google-apps.document" {
+		// Export Google Docs as plain text
+		resp, err := driveSrv.Files.Export(file.Id, "text/plain").Download()
+		if err == nil && (resp == nil || resp.Body == nil) {
+			err = errEmptyResponse
+		}
+		if err != nil {
+			log.Printf("Failed to export Google Doc: %v", err)
+			return nil, fmt.Errorf("unable to export Google Doc: %w", err)
+		}
+		content, readErr = io.ReadAll(resp.Body)
+		resp.Body.Close()
+	} else {
+		resp, err := driveSrv.Files.Get(file.Id).Download()
+		if err == nil && (resp == nil || resp.Body == nil) {
+			err = errEmptyResponse
+		}
+		if err != nil {
+			log.Printf("Failed to download file: %v", err)
+			return nil, fmt.Errorf("unable to download file: %w", err)
+		}
+		content, readErr = io.ReadAll(resp.Body)
+		resp.Body.Close()
 	}
 
-	return mcp.NewToolResultText(responseText), nil
+	if readErr != nil {
+		return nil, fmt.Errorf("unable to read file content: %w", readErr)
+	}
+	return content, nil
 }
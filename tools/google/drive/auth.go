@@ -2,45 +2,26 @@ package drive
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
 	"encoding/json"
-	"flag"
 	"fmt"
+	"html/template"
 	"log"
 	"net"
 	"net/http"
 	"os"
+	"sync"
+	"time"
 
 	"github.com/pkg/browser"
+	"github.com/pranavbalakrishnan4100/beacon-mcp-server/config"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
 	"google.golang.org/api/drive/v3"
-	"google.golang.org/api/option"
 )
 
-var (
-	credsFilePath string
-	tokenPath     string
-)
-
-type tokenSavingSource struct {
-	src       oauth2.TokenSource
-	tokenPath string
-}
-
-func (s *tokenSavingSource) Token() (*oauth2.Token, error) {
-	tok, err := s.src.Token()
-	if err != nil {
-		return nil, err
-	}
-	saveToken(s.tokenPath, tok)
-	return tok, nil
-}
-
-func init() {
-	flag.StringVar(&credsFilePath, "creds-file-path", "", "Path to OAuth2 credentials file")
-	flag.StringVar(&tokenPath, "token-path", "", "Path to store token file")
-}
-
 // // Authorize handles browser-based login and returns an authenticated Drive client.
 // func Authorize() (*drive.Service, error) {
 // 	//ln("AUTHORIZE")
@@ -78,98 +59,216 @@ func init() {
 // 	return srv, nil
 // }
 
-func Authorize() (*drive.Service, error) {
-	flag.Parse()
-
-	b, err := os.ReadFile(credsFilePath)
-	if err != nil {
-		return nil, fmt.Errorf("unable to read client secret file: %w", err)
+// newTokenSource builds a token source for the configured auth mode. In
+// "service_account" mode a non-empty subject enables domain-wide delegation as
+// that user.
+func newTokenSource(g config.Google, subject string) (oauth2.TokenSource, error) {
+	switch g.AuthMode {
+	case config.GoogleAuthOAuth:
+		return oauthTokenSource(g)
+	case config.GoogleAuthServiceAccount, config.GoogleAuthExternalAccount:
+		return credentialsTokenSource(g, subject)
+	default:
+		return nil, fmt.Errorf("unknown google auth mode %q", g.AuthMode)
 	}
+}
 
-	config, err := google.ConfigFromJSON(b, drive.DriveReadonlyScope)
+// oauthTokenSource authorizes as the user who owns the saved OAuth token, and
+// returns ErrLoginRequired if none has been saved yet.
+func oauthTokenSource(g config.Google) (oauth2.TokenSource, error) {
+	oauthConfig, err := oauthClientConfig(g)
 	if err != nil {
-		return nil, fmt.Errorf("unable to parse client secret file to config: %w", err)
+		return nil, err
 	}
 
-	tok, err := tokenFromFile(tokenPath)
+	tok, err := tokenFromFile(g.TokenFile)
 	if err != nil {
-		tok, err = getTokenFromWeb(config)
-		if err != nil {
-			return nil, fmt.Errorf("unable to get token from web: %w", err)
-		}
-		saveToken(tokenPath, tok)
+		return nil, ErrLoginRequired
 	}
 
 	// Wrap the token in a token source that auto-refreshes
-	tokenSource := config.TokenSource(context.Background(), tok)
+	tokenSource := oauthConfig.TokenSource(context.Background(), tok)
 
 	// Wrap token source to save updated token automatically after refresh
 	autoRefreshTokenSource := &tokenSavingSource{
 		src:       tokenSource,
-		tokenPath: tokenPath,
+		tokenPath: g.TokenFile,
+		last:      tok,
 	}
 
-	client := oauth2.NewClient(context.Background(), autoRefreshTokenSource)
-	srv, err := drive.NewService(context.Background(), option.WithHTTPClient(client))
+	return oauth2.ReuseTokenSource(tok, autoRefreshTokenSource), nil
+}
+
+// oauthClientConfig reads the OAuth client secret of g.
+func oauthClientConfig(g config.Google) (*oauth2.Config, error) {
+	b, err := os.ReadFile(g.CredentialsFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read client secret file: %w", err)
+	}
+	oauthConfig, err := google.ConfigFromJSON(b, drive.DriveReadonlyScope)
 	if err != nil {
-		return nil, fmt.Errorf("unable to retrieve Drive client: %w", err)
+		return nil, fmt.Errorf("unable to parse client secret file to config: %w", err)
 	}
+	return oauthConfig, nil
+}
 
-	return srv, nil
+// loginWithBrowser runs the browser flow and only then replaces the saved
+// token, so an aborted or failed login keeps the previous one.
+func loginWithBrowser(g config.Google) error {
+	oauthConfig, err := oauthClientConfig(g)
+	if err != nil {
+		return err
+	}
+	tok, err := getTokenFromWeb(oauthConfig)
+	if err != nil {
+		return fmt.Errorf("unable to get token from web: %w", err)
+	}
+	return saveToken(g.TokenFile, tok)
+}
+
+// credentialsTokenSource authorizes with a service-account key or an external
+// account (workload identity federation) configuration.
+func credentialsTokenSource(g config.Google, subject string) (oauth2.TokenSource, error) {
+	b, err := os.ReadFile(g.CredentialsFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read credentials file: %w", err)
+	}
+
+	var f struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("unable to parse credentials file: %w", err)
+	}
+	if f.Type != g.AuthMode {
+		return nil, fmt.Errorf("credentials file is of type %q but auth mode is %q", f.Type, g.AuthMode)
+	}
+	// Only service accounts can impersonate users; external accounts act as
+	// themselves and ignore the subject, as documented.
+	if g.AuthMode != config.GoogleAuthServiceAccount {
+		subject = ""
+	}
+
+	creds, err := google.CredentialsFromJSONWithParams(context.Background(), b, google.CredentialsParams{
+		Scopes:  []string{drive.DriveReadonlyScope},
+		Subject: subject,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to load credentials: %w", err)
+	}
+
+	return creds.TokenSource, nil
+}
+
+// webAuthTimeout bounds how long getTokenFromWeb waits for the user to finish
+// the consent screen.
+const webAuthTimeout = 5 * time.Minute
+
+var callbackPage = template.Must(template.New("callback").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Beacon</title></head>
+<body style="font-family: sans-serif; margin: 4em auto; max-width: 32em;">
+<h2>{{.Title}}</h2>
+<p>{{.Message}}</p>
+</body>
+</html>
+`))
+
+type callbackResult struct {
+	code string
+	err  error
 }
 
 func getTokenFromWeb(config *oauth2.Config) (*oauth2.Token, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), webAuthTimeout)
+	defer cancel()
+
 	// Use a local server to receive the code
 	listener, err := net.Listen("tcp", "localhost:0")
 	if err != nil {
 		return nil, err
 	}
 	port := listener.Addr().(*net.TCPAddr).Port
-	redirectURL := fmt.Sprintf("http://localhost:%d", port)
+	redirectURL := fmt.Sprintf("http://localhost:%d/callback", port)
 	config.RedirectURL = redirectURL
 
-	codeCh := make(chan string)
+	state, err := randomState()
+	if err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("unable to generate state: %w", err)
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	// Buffered so the handler never blocks; only the first result is used.
+	resultCh := make(chan callbackResult, 1)
+	var once sync.Once
 	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/callback" {
+			http.NotFound(w, r)
+			return
+		}
 		if err := r.ParseForm(); err != nil {
 			http.Error(w, "Failed to parse response", http.StatusBadRequest)
 			return
 		}
-		code := r.FormValue("code")
-		fmt.Fprint(w, "Authorization successful. You may close this window.")
-		codeCh <- code
+		// Ignore requests that don't carry our state, e.g. from other local
+		// processes probing the port.
+		if subtle.ConstantTimeCompare([]byte(r.FormValue("state")), []byte(state)) != 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			renderCallbackPage(w, "Authorization failed", "The response did not match this sign-in attempt. Please retry from Beacon.")
+			return
+		}
+
+		var res callbackResult
+		if e := r.FormValue("error"); e != "" {
+			if e == "access_denied" {
+				res.err = fmt.Errorf("access was denied by the user")
+			} else {
+				res.err = fmt.Errorf("authorization failed: %s %s", e, r.FormValue("error_description"))
+			}
+		} else if res.code = r.FormValue("code"); res.code == "" {
+			res.err = fmt.Errorf("authorization response did not include a code")
+		}
+
+		if res.err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			renderCallbackPage(w, "Authorization failed", fmt.Sprintf("Beacon was not authorized: %v. You may close this window.", res.err))
+		} else {
+			renderCallbackPage(w, "Authorization successful", "Beacon can now access Google Drive. You may close this window.")
+		}
+		once.Do(func() { resultCh <- res })
 	})}
 
 	go func() {
 		_ = srv.Serve(listener)
 	}()
+	defer srv.Shutdown(context.Background())
 
-	authURL := config.AuthCodeURL("state-token", oauth2.AccessTypeOffline)
-	//fmt.Printf("Opening browser for auth: %s\n", authURL)
+	authURL := config.AuthCodeURL(state, oauth2.AccessTypeOffline, oauth2.S256ChallengeOption(verifier))
+	log.Printf("Opening browser for Google authorization: %s", authURL)
 	_ = browser.OpenURL(authURL)
 
-	code := <-codeCh
-	_ = srv.Shutdown(context.Background())
-
-	return config.Exchange(context.Background(), code)
+	select {
+	case res := <-resultCh:
+		if res.err != nil {
+			return nil, res.err
+		}
+		return config.Exchange(ctx, res.code, oauth2.VerifierOption(verifier))
+	case <-ctx.Done():
+		return nil, fmt.Errorf("timed out waiting for authorization: %w", ctx.Err())
+	}
 }
 
-func tokenFromFile(file string) (*oauth2.Token, error) {
-	f, err := os.Open(file)
-	if err != nil {
-		return nil, err
+// randomState returns an unguessable value for the OAuth state parameter.
+func randomState() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
 	}
-	defer f.Close()
-	token := &oauth2.Token{}
-	err = json.NewDecoder(f).Decode(token)
-	return token, err
+	return base64.RawURLEncoding.EncodeToString(b), nil
 }
 
-func saveToken(path string, token *oauth2.Token) {
-	//fmt.Printf("Saving token to %s\n", path)
-	f, err := os.Create(path)
-	if err != nil {
-		log.Fatalf("Unable to save oauth token: %v", err)
-	}
-	defer f.Close()
-	json.NewEncoder(f).Encode(token)
+func renderCallbackPage(w http.ResponseWriter, title, message string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_ = callbackPage.Execute(w, struct{ Title, Message string }{title, message})
 }
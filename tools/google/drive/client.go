@@ -0,0 +1,277 @@
+package drive
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pranavbalakrishnan4100/beacon-mcp-server/config"
+	"golang.org/x/oauth2"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/option"
+)
+
+// Client holds a Drive service that is authorized once and shared by every tool
+// call. Access tokens are refreshed lazily by the underlying token source; once
+// the refresh token has been revoked, the user has to log in again.
+type Client struct {
+	account string
+	subject string
+
+	mu  sync.Mutex
+	srv *drive.Service
+	ts  oauth2.TokenSource
+}
+
+// AuthStatus describes the state of a Client's credentials.
+type AuthStatus struct {
+	Account    string
+	Mode       string
+	Subject    string
+	Authorized bool
+	Expiry     time.Time
+	Err        error
+}
+
+// ErrLoginRequired is returned when an OAuth account has no usable token. The
+// browser flow never runs while serving, since it would stall the tool call.
+var ErrLoginRequired = errors.New("google login required, run `beacon auth google login`")
+
+// revokeURL is Google's OAuth token revocation endpoint.
+const revokeURL = "https://oauth2.googleapis.com/revoke"
+
+var (
+	clientsMu sync.Mutex
+	clients   = map[clientKey]*Client{}
+)
+
+type clientKey struct {
+	account string
+	subject string
+}
+
+// ClientFor returns the shared Client of the named account acting as subject.
+func ClientFor(account, subject string) *Client {
+	if account == "" {
+		account = config.DefaultAccount
+	}
+	key := clientKey{account: account, subject: subject}
+
+	clientsMu.Lock()
+	defer clientsMu.Unlock()
+	c, ok := clients[key]
+	if !ok {
+		c = &Client{account: account, subject: subject}
+		clients[key] = c
+	}
+	return c
+}
+
+// Service returns the Drive service, authorizing if this is the first call.
+// A revoked refresh token yields ErrLoginRequired; the saved token is kept
+// until `beacon auth google login` replaces it.
+func (c *Client) Service() (*drive.Service, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.srv != nil {
+		_, err := c.ts.Token()
+		if err == nil {
+			return c.srv, nil
+		}
+		if !isRevoked(err) {
+			return nil, fmt.Errorf("unable to refresh Google token: %w", err)
+		}
+		log.Printf("Google refresh token for account %s was revoked", c.account)
+		c.srv, c.ts = nil, nil
+		return nil, fmt.Errorf("token of account %s revoked: %w", c.account, ErrLoginRequired)
+	}
+
+	if err := c.authorize(); err != nil {
+		return nil, err
+	}
+	return c.srv, nil
+}
+
+// Login authorizes from scratch, running the browser flow for OAuth accounts
+// even if a token was saved before. The saved token is only replaced once
+// the flow succeeded.
+func (c *Client) Login() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	g, err := c.settings()
+	if err != nil {
+		return err
+	}
+	if g.AuthMode == config.GoogleAuthOAuth {
+		if err := loginWithBrowser(g); err != nil {
+			return err
+		}
+	}
+	c.srv, c.ts = nil, nil
+	return c.authorize()
+}
+
+// Logout revokes the saved OAuth token with Google and deletes it. Service and
+// external accounts have nothing to revoke, so only the cached client is
+// dropped.
+func (c *Client) Logout() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	g, err := c.settings()
+	if err != nil {
+		return err
+	}
+	if g.AuthMode == config.GoogleAuthOAuth {
+		tok, err := tokenFromFile(g.TokenFile)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("unable to read saved token: %w", err)
+		}
+		if err == nil {
+			if err := revokeToken(tok); err != nil {
+				return fmt.Errorf("%w; the token in %s was kept, delete it to log out without revoking", err, g.TokenFile)
+			}
+		}
+	}
+	return c.reset()
+}
+
+// Email returns the address of the Google user the client acts as.
+func (c *Client) Email() (string, error) {
+	srv, err := c.Service()
+	if err != nil {
+		return "", err
+	}
+	about, err := srv.About.Get().Fields("user(emailAddress)").Do()
+	if err != nil {
+		return "", err
+	}
+	return about.User.EmailAddress, nil
+}
+
+// Status reports whether the client currently holds usable credentials without
+// starting an interactive authorization.
+func (c *Client) Status() AuthStatus {
+	status := AuthStatus{Account: c.account, Subject: c.subject}
+	g, err := c.settings()
+	if err != nil {
+		status.Err = err
+		return status
+	}
+	status.Mode = g.AuthMode
+
+	c.mu.Lock()
+	ts := c.ts
+	c.mu.Unlock()
+
+	if ts == nil {
+		if g.AuthMode == config.GoogleAuthOAuth {
+			if _, err := tokenFromFile(g.TokenFile); err != nil {
+				status.Err = fmt.Errorf("no saved token: %w", err)
+				return status
+			}
+		}
+		// Build a token source without caching it, so a status check
+		// leaves the client as it is.
+		if ts, err = newTokenSource(g, c.subject); err != nil {
+			status.Err = err
+			return status
+		}
+	}
+
+	tok, err := ts.Token()
+	if err != nil {
+		status.Err = err
+		return status
+	}
+	status.Authorized = true
+	status.Expiry = tok.Expiry
+	return status
+}
+
+func (c *Client) authorize() error {
+	g, err := c.settings()
+	if err != nil {
+		return err
+	}
+
+	ts, err := newTokenSource(g, c.subject)
+	if err != nil {
+		return err
+	}
+
+	srv, err := drive.NewService(context.Background(), option.WithHTTPClient(oauth2.NewClient(context.Background(), ts)))
+	if err != nil {
+		return fmt.Errorf("unable to retrieve Drive client: %w", err)
+	}
+
+	c.srv, c.ts = srv, ts
+	return nil
+}
+
+// reset drops the cached service and, in "oauth" mode, the saved token, once
+// it has been revoked by Logout.
+func (c *Client) reset() error {
+	c.srv, c.ts = nil, nil
+
+	g, err := c.settings()
+	if err != nil {
+		return err
+	}
+	if g.AuthMode == config.GoogleAuthOAuth {
+		if err := os.Remove(g.TokenFile); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("unable to remove token: %w", err)
+		}
+	}
+	return nil
+}
+
+func (c *Client) settings() (config.Google, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return config.Google{}, err
+	}
+	return cfg.GoogleAccount(c.account)
+}
+
+// revokeToken revokes the refresh token, or the access token if there is none.
+// Google rejecting the request, e.g. because it doesn't know the token, is
+// reported as an error, as the token can't be known to be revoked.
+func revokeToken(tok *oauth2.Token) error {
+	t := tok.RefreshToken
+	if t == "" {
+		t = tok.AccessToken
+	}
+	resp, err := http.PostForm(revokeURL, url.Values{"token": {t}})
+	if err != nil {
+		return fmt.Errorf("unable to revoke token: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var body struct {
+			Error       string `json:"error"`
+			Description string `json:"error_description"`
+		}
+		if json.NewDecoder(io.LimitReader(resp.Body, 1<<16)).Decode(&body) == nil && body.Error != "" {
+			return fmt.Errorf("unable to revoke token: %s: %s %s", resp.Status, body.Error, body.Description)
+		}
+		return fmt.Errorf("unable to revoke token: %s", resp.Status)
+	}
+	return nil
+}
+
+// isRevoked reports whether err means the refresh token is no longer valid.
+func isRevoked(err error) bool {
+	var rErr *oauth2.RetrieveError
+	return errors.As(err, &rErr) && rErr.ErrorCode == "invalid_grant"
+}
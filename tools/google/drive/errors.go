@@ -0,0 +1,29 @@
+package drive
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// errSubjectNotAllowed is returned when a caller asks to impersonate a
+	// user the account's allowed_subjects doesn't list.
+	errSubjectNotAllowed = errors.New("subject not allowed")
+	// errAuthorize wraps failures to get an authorized Drive client.
+	errAuthorize = errors.New("unable to authorize")
+	// errEmptyResponse is returned when Drive answers a download without a
+	// body.
+	errEmptyResponse = errors.New("empty response")
+)
+
+// describeError explains err in words the model can pass on to the user.
+func describeError(err error) string {
+	switch {
+	case errors.Is(err, errSubjectNotAllowed):
+		return fmt.Sprintf("Not allowed to access Google Drive as that user (%v). Ask the user to add them to allowed_subjects in Beacon's config.", err)
+	case errors.Is(err, errAuthorize):
+		return fmt.Sprintf("Unable to authorize and connect to Google (%v). Ask the user to run `beacon auth google login`.", err)
+	default:
+		return fmt.Sprintf("Google Drive returned an error: %v.", err)
+	}
+}
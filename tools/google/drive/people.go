@@ -0,0 +1,131 @@
+package drive
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/pranavbalakrishnan4100/beacon-mcp-server/config"
+	"google.golang.org/api/drive/v3"
+)
+
+// Contributor is someone who owns or last modified Drive files about a topic.
+type Contributor struct {
+	Name  string
+	Email string
+	// Owned and Modified count the matching files the person owns and was
+	// the last to modify.
+	Owned    int
+	Modified int
+	// Files links to some of those files.
+	Files []string
+}
+
+// maxContributorFiles bounds how many files are linked per contributor.
+const maxContributorFiles = 3
+
+// TopicContributors returns the owners and last modifiers of the files about
+// topic across every configured Google account, most files first. Accounts
+// that can't be searched are skipped.
+func TopicContributors(topic string) ([]Contributor, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	byEmail := map[string]*Contributor{}
+	var firstErr error
+	searched := 0
+	for _, account := range cfg.GoogleAccountNames() {
+		files, err := contributorFiles(cfg, account, topic)
+		if err != nil {
+			log.Printf("Google account %s: %v", account, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		searched++
+
+		for _, f := range files {
+			add := func(u *drive.User, owner bool) {
+				if u == nil || u.EmailAddress == "" {
+					return
+				}
+				key := strings.ToLower(u.EmailAddress)
+				c, ok := byEmail[key]
+				if !ok {
+					c = &Contributor{Name: u.DisplayName, Email: u.EmailAddress}
+					byEmail[key] = c
+				}
+				if owner {
+					c.Owned++
+				} else {
+					c.Modified++
+				}
+				if len(c.Files) < maxContributorFiles && f.WebViewLink != "" && !contains(c.Files, f.WebViewLink) {
+					c.Files = append(c.Files, f.WebViewLink)
+				}
+			}
+			for _, o := range f.Owners {
+				add(o, true)
+			}
+			add(f.LastModifyingUser, false)
+		}
+	}
+	if searched == 0 && firstErr != nil {
+		return nil, firstErr
+	}
+
+	contributors := make([]Contributor, 0, len(byEmail))
+	for _, c := range byEmail {
+		contributors = append(contributors, *c)
+	}
+	sort.Slice(contributors, func(i, j int) bool {
+		a, b := contributors[i], contributors[j]
+		if a.Owned+a.Modified != b.Owned+b.Modified {
+			return a.Owned+a.Modified > b.Owned+b.Modified
+		}
+		return a.Email < b.Email
+	})
+	return contributors, nil
+}
+
+func contributorFiles(cfg *config.Config, account, topic string) ([]*drive.File, error) {
+	g, err := cfg.GoogleAccount(account)
+	if err != nil {
+		return nil, err
+	}
+	driveSrv, err := ClientFor(account, g.Subject).Service()
+	if err != nil {
+		return nil, fmt.Errorf("unable to authorize: %w", err)
+	}
+
+	files, err := driveSrv.Files.List().
+		Q(topicQuery(topic)).
+		IncludeItemsFromAllDrives(true).
+		SupportsAllDrives(true).
+		Fields("files(id, webViewLink, owners(displayName, emailAddress), lastModifyingUser(displayName, emailAddress))").
+		PageSize(25).
+		Do()
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve files: %w", err)
+	}
+	return files.Files, nil
+}
+
+// topicQuery builds the Drive search for files mentioning topic.
+func topicQuery(topic string) string {
+	q := strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(topic)
+	return fmt.Sprintf("fullText contains '%s' or name contains '%s'", q, q)
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
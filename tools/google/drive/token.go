@@ -0,0 +1,103 @@
+package drive
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/pranavbalakrishnan4100/beacon-mcp-server/tools/filelock"
+	"golang.org/x/oauth2"
+)
+
+// tokenSavingSource persists every token its source hands out that differs
+// from the last one saved, so refreshed tokens survive restarts.
+type tokenSavingSource struct {
+	src       oauth2.TokenSource
+	tokenPath string
+
+	mu   sync.Mutex
+	last *oauth2.Token
+}
+
+func (s *tokenSavingSource) Token() (*oauth2.Token, error) {
+	tok, err := s.src.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if sameToken(s.last, tok) {
+		return tok, nil
+	}
+	if err := saveToken(s.tokenPath, tok); err != nil {
+		return nil, err
+	}
+	s.last = tok
+	return tok, nil
+}
+
+func tokenFromFile(file string) (*oauth2.Token, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	token := &oauth2.Token{}
+	err = json.NewDecoder(f).Decode(token)
+	return token, err
+}
+
+// saveToken atomically replaces the token file with a copy readable only by
+// the current user. An exclusive lock on a sibling ".lock" file keeps several
+// Beacon processes sharing the same token file from clobbering each other.
+func saveToken(path string, token *oauth2.Token) error {
+	unlock, err := filelock.Lock(path + ".lock")
+	if err != nil {
+		return fmt.Errorf("unable to lock token file: %w", err)
+	}
+	defer unlock()
+
+	if existing, err := tokenFromFile(path); err == nil && sameToken(existing, token) {
+		return nil
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("unable to save oauth token: %w", err)
+	}
+	tmp := f.Name()
+	defer os.Remove(tmp)
+
+	if err := f.Chmod(0600); err != nil {
+		f.Close()
+		return fmt.Errorf("unable to save oauth token: %w", err)
+	}
+	if err := json.NewEncoder(f).Encode(token); err != nil {
+		f.Close()
+		return fmt.Errorf("unable to save oauth token: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("unable to save oauth token: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("unable to save oauth token: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("unable to save oauth token: %w", err)
+	}
+	return nil
+}
+
+func sameToken(a, b *oauth2.Token) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.AccessToken == b.AccessToken &&
+		a.RefreshToken == b.RefreshToken &&
+		a.TokenType == b.TokenType &&
+		a.Expiry.Equal(b.Expiry)
+}
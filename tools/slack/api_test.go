@@ -0,0 +1,213 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/slack-go/slack"
+)
+
+// testConversations serves conversations.replies for a channel holding a
+// standalone message and a thread with two replies, the way Slack does: the
+// thread root comes first, then the messages within oldest and latest.
+func testConversations(t *testing.T) (*Client, func() []url.Values) {
+	t.Helper()
+
+	threads := [][]slack.Message{
+		{
+			testMessage("100.000", "", 0),
+		},
+		{
+			testMessage("200.000", "200.000", 2),
+			testMessage("201.000", "200.000", 0),
+			testMessage("202.000", "200.000", 0),
+		},
+	}
+
+	var (
+		mu    sync.Mutex
+		calls []url.Values
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/conversations.replies" {
+			http.Error(w, "unexpected method "+r.URL.Path, http.StatusNotFound)
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		calls = append(calls, r.Form)
+		mu.Unlock()
+
+		ts, oldest, latest := r.Form.Get("ts"), r.Form.Get("oldest"), r.Form.Get("latest")
+		var messages []slack.Message
+		for _, thread := range threads {
+			if !containsTimestamp(thread, ts) {
+				continue
+			}
+			for i, msg := range thread {
+				if i == 0 || (oldest == "" || msg.Timestamp >= oldest) && (latest == "" || msg.Timestamp <= latest) {
+					messages = append(messages, msg)
+				}
+			}
+		}
+		if messages == nil {
+			json.NewEncoder(w).Encode(map[string]any{"ok": false, "error": "thread_not_found"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"ok": true, "messages": messages})
+	}))
+	t.Cleanup(srv.Close)
+
+	api := &Client{
+		Client: slack.New("xoxp-test", slack.OptionAPIURL(srv.URL+"/")),
+		limits: &rateLimits{buckets: map[string]*bucket{}},
+		dir:    newDirectory(),
+	}
+	return api, func() []url.Values {
+		mu.Lock()
+		defer mu.Unlock()
+		return calls
+	}
+}
+
+func testMessage(ts, threadTs string, replyCount int) slack.Message {
+	var msg slack.Message
+	msg.Timestamp = ts
+	msg.ThreadTimestamp = threadTs
+	msg.ReplyCount = replyCount
+	msg.Text = "message " + ts
+	return msg
+}
+
+func containsTimestamp(messages []slack.Message, ts string) bool {
+	for _, msg := range messages {
+		if msg.Timestamp == ts {
+			return true
+		}
+	}
+	return false
+}
+
+func TestGetFullConversationForMatch(t *testing.T) {
+	// lookup is the request for a single message, thread the one for a
+	// whole thread.
+	lookup := func(ts string) [3]string { return [3]string{ts, ts, ts} }
+	thread := func(ts string) [3]string { return [3]string{ts, "", ""} }
+
+	tests := []struct {
+		name      string
+		match     slack.SearchMessage
+		wantTs    []string
+		wantCalls [][3]string
+	}{
+		{
+			name:      "top-level message",
+			match:     testMatch("100.000", ""),
+			wantTs:    []string{"100.000"},
+			wantCalls: [][3]string{lookup("100.000")},
+		},
+		{
+			name:      "thread root",
+			match:     testMatch("200.000", ""),
+			wantTs:    []string{"200.000", "201.000", "202.000"},
+			wantCalls: [][3]string{lookup("200.000"), thread("200.000")},
+		},
+		{
+			name:      "reply",
+			match:     testMatch("202.000", ""),
+			wantTs:    []string{"200.000", "201.000", "202.000"},
+			wantCalls: [][3]string{lookup("202.000"), thread("200.000")},
+		},
+		{
+			name:      "reply with thread in permalink",
+			match:     testMatch("201.000", "200.000"),
+			wantTs:    []string{"200.000", "201.000", "202.000"},
+			wantCalls: [][3]string{thread("200.000")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api, calls := testConversations(t)
+
+			messages, err := GetFullConversationForMatch(context.Background(), api, tt.match)
+			if err != nil {
+				t.Fatalf("GetFullConversationForMatch: %v", err)
+			}
+			var gotTs []string
+			for _, msg := range messages {
+				gotTs = append(gotTs, msg.Timestamp)
+			}
+			if !reflect.DeepEqual(gotTs, tt.wantTs) {
+				t.Errorf("messages = %v, want %v", gotTs, tt.wantTs)
+			}
+
+			var gotCalls [][3]string
+			for _, form := range calls() {
+				if form.Get("channel") != "C1" {
+					t.Errorf("channel = %q, want C1", form.Get("channel"))
+				}
+				gotCalls = append(gotCalls, [3]string{form.Get("ts"), form.Get("oldest"), form.Get("latest")})
+			}
+			if !reflect.DeepEqual(gotCalls, tt.wantCalls) {
+				t.Errorf("conversations.replies calls (ts, oldest, latest) = %v, want %v", gotCalls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func testMatch(ts, threadTs string) slack.SearchMessage {
+	permalink := "https://example.slack.com/archives/C1/p" + ts[:3] + ts[4:]
+	if threadTs != "" {
+		permalink += "?thread_ts=" + threadTs + "&cid=C1"
+	}
+	return slack.SearchMessage{
+		Channel:   slack.CtxChannel{ID: "C1"},
+		Timestamp: ts,
+		Permalink: permalink,
+	}
+}
+
+// Groups repeating a conversation, as when workspaces or the digest feed the
+// same list, share a single fetch.
+func TestExpandThreadsFetchesEachThreadOnce(t *testing.T) {
+	key := threadKey{ChannelID: "C1", ThreadTs: "200.000"}
+	groups := []*threadGroup{
+		{Key: key, Matches: []slack.SearchMessage{testMatch("201.000", "200.000")}},
+		{Key: threadKey{ChannelID: "C1", ThreadTs: "100.000"}, Matches: []slack.SearchMessage{testMatch("100.000", "")}},
+		{Key: key, Matches: []slack.SearchMessage{testMatch("202.000", "200.000")}},
+	}
+
+	var (
+		mu      sync.Mutex
+		fetched = map[threadKey]int{}
+	)
+	expandThreads(context.Background(), groups, func(ctx context.Context, g *threadGroup) ([]slack.Message, error) {
+		mu.Lock()
+		fetched[g.Key]++
+		mu.Unlock()
+		return []slack.Message{testMessage(g.Key.ThreadTs, "", 0)}, nil
+	})
+
+	for k, n := range fetched {
+		if n != 1 {
+			t.Errorf("%v fetched %d times, want once", k, n)
+		}
+	}
+	if len(fetched) != 2 {
+		t.Errorf("fetched %d conversations, want 2", len(fetched))
+	}
+	for i, g := range groups {
+		if len(g.Messages) != 1 || g.Messages[0].Timestamp != g.Key.ThreadTs || g.Err != nil {
+			t.Errorf("group %d = %+v, %v, want its conversation", i, g.Messages, g.Err)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package slack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/pranavbalakrishnan4100/beacon-mcp-server/config"
+	"github.com/slack-go/slack"
+)
+
+// maxBackfillMessages bounds how many top-level messages of one channel a
+// backfill pages in; older ones are left out.
+const maxBackfillMessages = 10000
+
+// Backfill pages the recent history of every channel the token's user or bot
+// is a member of, threads included, into the local message index of the named
+// workspace, or of every configured workspace in turn when workspace is
+// empty. Channels that were backfilled before only have their newer messages
+// fetched; replies added since to older threads are left to `beacon listen`.
+func Backfill(ctx context.Context, workspace string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	names := cfg.SlackWorkspaceNames()
+	if workspace != "" {
+		if _, err := cfg.SlackWorkspace(workspace); err != nil {
+			return err
+		}
+		names = []string{workspace}
+	}
+
+	var firstErr error
+	for _, name := range names {
+		if len(names) > 1 {
+			log.Printf("Indexing Slack workspace %s", name)
+		}
+		if err := backfillWorkspace(ctx, name); err != nil {
+			if len(names) > 1 {
+				log.Printf("Unable to index Slack workspace %s: %v", name, err)
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+			if ctx.Err() != nil {
+				break
+			}
+		}
+	}
+	return firstErr
+}
+
+func backfillWorkspace(ctx context.Context, workspace string) error {
+	api, err := newClient(workspace)
+	if err != nil {
+		return err
+	}
+	if api.cfg.IndexFile == "" {
+		return errors.New("no slack.index_file configured")
+	}
+	idx, err := openIndex(api.cfg.IndexFile)
+	if err != nil {
+		return err
+	}
+
+	channels, err := listChannels(ctx, api, channelFilter{
+		Types:           []string{"public_channel", "private_channel"},
+		MemberOnly:      true,
+		ExcludeArchived: true,
+	})
+	if err != nil {
+		return fmt.Errorf("unable to list channels: %w", err)
+	}
+	if len(channels) == 0 {
+		log.Printf("Not a member of any Slack channel, invite Beacon to the channels to search")
+		return nil
+	}
+
+	since := time.Now().AddDate(0, 0, -api.cfg.BackfillDays)
+	var firstErr error
+	for _, ch := range channels {
+		idx.setChannelName(ch.ID, ch.Name)
+		n, err := backfillChannel(ctx, api, idx, ch.ID, since)
+		if err != nil {
+			log.Printf("Unable to index #%s: %v", ch.Name, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+			if ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+		log.Printf("Indexed %d messages from #%s", n, ch.Name)
+		// Save as we go so an interrupted backfill keeps its progress.
+		if err := idx.save(); err != nil {
+			return err
+		}
+	}
+	if err := idx.save(); err != nil {
+		return err
+	}
+	return firstErr
+}
+
+// backfillChannel indexes the messages of channelID posted since the later of
+// since and its previous backfill, and returns how many there were. A range
+// holding more than maxBackfillMessages top-level messages is paged backwards
+// over several runs, one window of them per run.
+func backfillChannel(ctx context.Context, api *Client, idx *messageIndex, channelID string, since time.Time) (int, error) {
+	oldest := slackTimestamp(since)
+	if t, ok := parseTimestamp(idx.backfilledUntil(channelID)); ok && t.After(since) {
+		oldest = idx.backfilledUntil(channelID)
+	}
+	progress, resumed := idx.backfillInProgress(channelID)
+	latest := progress.Oldest
+	if !resumed {
+		progress.Until = slackTimestamp(time.Now())
+		latest = progress.Until
+	}
+
+	roots, truncated, err := fetchHistory(ctx, api, channelID, oldest, latest, maxBackfillMessages)
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for _, root := range roots {
+		messages := []slack.Message{root}
+		if root.ReplyCount > 0 {
+			thread, err := fetchThreadMessages(ctx, api, channelID, root.Timestamp)
+			if err != nil {
+				if ctx.Err() != nil {
+					return n, err
+				}
+				log.Printf("Unable to index the replies of %s/%s: %v", channelID, root.Timestamp, err)
+			} else {
+				messages = thread
+			}
+		}
+		for _, m := range messages {
+			if !indexedSubTypes[m.SubType] {
+				continue
+			}
+			idx.put(indexedMessage{
+				ChannelID: channelID,
+				User:      m.User,
+				Username:  m.Username,
+				Text:      m.Text,
+				Timestamp: m.Timestamp,
+				ThreadTs:  m.ThreadTimestamp,
+			})
+			n++
+		}
+	}
+
+	if truncated && len(roots) > 0 {
+		// The history is returned newest first, so the next run continues
+		// before the last message.
+		progress.Oldest = roots[len(roots)-1].Timestamp
+		idx.setBackfillInProgress(channelID, progress)
+		log.Printf("Indexed the newest %d messages of %s, the next backfill continues with older ones", maxBackfillMessages, channelID)
+		return n, nil
+	}
+	idx.setBackfilled(channelID, progress.Until)
+	return n, nil
+}
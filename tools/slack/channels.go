@@ -0,0 +1,156 @@
+package slack
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/slack-go/slack"
+)
+
+// channelsPageSize is how many conversations are asked for per
+// conversations.list call.
+const channelsPageSize = 200
+
+// channelTypes maps the type filter values of getChannelsFromSlack to the
+// types conversations.list understands.
+var channelTypes = map[string]string{
+	"public":  "public_channel",
+	"private": "private_channel",
+	"mpim":    "mpim",
+	"im":      "im",
+}
+
+// ChannelInfo describes a Slack conversation as handed to the model.
+type ChannelInfo struct {
+	// Workspace names the workspace the channel is in when several are
+	// configured.
+	Workspace string `json:"workspace,omitempty"`
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Type      string `json:"type"`
+	Topic     string `json:"topic,omitempty"`
+	Purpose   string `json:"purpose,omitempty"`
+	Members   int    `json:"member_count"`
+	IsMember  bool   `json:"is_member"`
+	Archived  bool   `json:"archived,omitempty"`
+}
+
+// channelFilter selects the conversations listChannels returns.
+type channelFilter struct {
+	Types           []string
+	MemberOnly      bool
+	ExcludeArchived bool
+	NamePrefix      string
+}
+
+// listChannels pages through every conversation visible to the token and
+// returns those matching f.
+func listChannels(ctx context.Context, api *Client, f channelFilter) ([]ChannelInfo, error) {
+	params := &slack.GetConversationsParameters{
+		ExcludeArchived: f.ExcludeArchived,
+		Limit:           channelsPageSize,
+		Types:           f.Types,
+	}
+	prefix := strings.ToLower(strings.TrimPrefix(f.NamePrefix, "#"))
+
+	var channels []ChannelInfo
+	for {
+		page, err := api.GetConversations(ctx, params)
+		if err != nil {
+			return channels, err
+		}
+		for _, ch := range page.Channels {
+			info := channelInfo(ctx, api, ch)
+			if f.MemberOnly && !info.IsMember {
+				continue
+			}
+			if prefix != "" && !strings.HasPrefix(strings.ToLower(info.Name), prefix) {
+				continue
+			}
+			channels = append(channels, info)
+		}
+		if page.NextCursor == "" {
+			return channels, nil
+		}
+		params.Cursor = page.NextCursor
+	}
+}
+
+func channelInfo(ctx context.Context, api *Client, ch slack.Channel) ChannelInfo {
+	info := ChannelInfo{
+		Workspace: api.label,
+		ID:        ch.ID,
+		Name:      ch.Name,
+		Type:      "public",
+		Topic:     api.renderMrkdwn(ctx, ch.Topic.Value),
+		Purpose:   api.renderMrkdwn(ctx, ch.Purpose.Value),
+		Members:   ch.NumMembers,
+		IsMember:  ch.IsMember,
+		Archived:  ch.IsArchived,
+	}
+	switch {
+	case ch.IsIM:
+		// Direct messages have no name; show who they are with. The token's
+		// owner is always part of them.
+		info.Type = "im"
+		info.Name = api.userName(ctx, ch.User)
+		info.Members = 2
+		info.IsMember = true
+	case ch.IsMpIM:
+		info.Type = "mpim"
+		info.IsMember = true
+	case ch.IsPrivate:
+		// Private channels are only listed to their members.
+		info.Type = "private"
+		info.IsMember = true
+	}
+	return info
+}
+
+func channelFilterFromRequest(request mcp.CallToolRequest) (channelFilter, error) {
+	f := channelFilter{
+		MemberOnly:      boolArgument(request, "member_only"),
+		ExcludeArchived: true,
+	}
+	if v, ok := request.Params.Arguments["exclude_archived"].(bool); ok {
+		f.ExcludeArchived = v
+	}
+	f.NamePrefix, _ = request.Params.Arguments["name_prefix"].(string)
+
+	types := stringsArgument(request, "types")
+	if len(types) == 0 {
+		types = []string{"public", "private"}
+	}
+	for _, t := range types {
+		apiType, ok := channelTypes[t]
+		if !ok {
+			return f, fmt.Errorf("unknown channel type %q, expected public, private, mpim or im", t)
+		}
+		f.Types = append(f.Types, apiType)
+	}
+	return f, nil
+}
+
+func renderChannels(channels []ChannelInfo) string {
+	var b strings.Builder
+	for _, ch := range channels {
+		fmt.Fprintf(&b, "- #%s (`%s`, %s, %d members", ch.Name, ch.ID, ch.Type, ch.Members)
+		if ch.Workspace != "" {
+			fmt.Fprintf(&b, ", %s workspace", ch.Workspace)
+		}
+		if ch.Archived {
+			b.WriteString(", archived")
+		}
+		b.WriteString(")")
+		if ch.Topic != "" {
+			fmt.Fprintf(&b, "\n  Topic: %s", ch.Topic)
+		}
+		if ch.Purpose != "" {
+			fmt.Fprintf(&b, "\n  Purpose: %s", ch.Purpose)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,328 @@
+package slack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/pranavbalakrishnan4100/beacon-mcp-server/config"
+	"github.com/slack-go/slack"
+)
+
+// Per-minute request allowances of Slack's rate limit tiers.
+const (
+	tier2 = 20
+	tier3 = 50
+	tier4 = 100
+)
+
+// methodTiers maps the Slack Web API methods Beacon uses to their tier.
+// Methods not listed are treated as tier 3.
+var methodTiers = map[string]int{
+	"search.messages":       tier2,
+	"conversations.list":    tier2,
+	"conversations.history": tier3,
+	"conversations.replies": tier3,
+	"conversations.info":    tier3,
+	"users.conversations":   tier3,
+	"users.info":            tier4,
+	"auth.test":             tier4,
+	"usergroups.list":       tier2,
+	"chat.postMessage":      tier4,
+	"chat.scheduleMessage":  tier3,
+	"reactions.add":         tier3,
+	"files.info":            tier4,
+	"users.lookupByEmail":   tier3,
+	"pins.list":             tier2,
+	"bookmarks.list":        tier3,
+	"team.info":             tier3,
+	// Not a Web API method, but downloads are throttled like one.
+	"files.download": tier4,
+}
+
+// writeMethods change something in Slack. They are retried when rate limited,
+// which Slack guarantees did nothing, but not after a network failure, which
+// may have happened after the message was posted.
+var writeMethods = map[string]bool{
+	"chat.postMessage":     true,
+	"chat.scheduleMessage": true,
+	"reactions.add":        true,
+}
+
+const (
+	maxAttempts  = 4
+	baseBackoff  = 500 * time.Millisecond
+	maxBackoff   = 10 * time.Second
+	minRateBurst = 1
+)
+
+// Client wraps a slack.Client so that every call waits for its method's rate
+// limit and transient failures are retried. It is shared by all tool calls
+// to the same workspace, and its rate limits by every workspace using the
+// same token, as Slack counts them per token, so the limits hold across
+// concurrent requests.
+type Client struct {
+	*slack.Client
+
+	// Workspace names the configured workspace the client talks to.
+	Workspace string
+	cfg       config.Slack
+	// label tags the results from this workspace, and is empty when there is
+	// only one workspace to tell apart.
+	label string
+
+	limits *rateLimits
+
+	dir directory
+}
+
+// rateLimits holds the token buckets of one Slack token, per method.
+type rateLimits struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+var (
+	clientsMu sync.Mutex
+	clients   = map[string]*Client{}
+	// limits is keyed by token, so that the workspaces of an Enterprise
+	// Grid org sharing an org-wide token share its limits too.
+	limits = map[string]*rateLimits{}
+)
+
+// newClient returns the shared Slack client of the named workspace, or of
+// the default one when name is empty.
+func newClient(name string) (*Client, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		name = config.DefaultAccount
+	}
+	s, err := cfg.SlackWorkspace(name)
+	if err != nil {
+		return nil, err
+	}
+	if s.Token == "" {
+		if name != config.DefaultAccount {
+			return nil, fmt.Errorf("%w for workspace %s", errNoToken, name)
+		}
+		return nil, errNoToken
+	}
+
+	clientsMu.Lock()
+	defer clientsMu.Unlock()
+	c, ok := clients[name]
+	if !ok {
+		l, ok := limits[s.Token]
+		if !ok {
+			l = &rateLimits{buckets: map[string]*bucket{}}
+			limits[s.Token] = l
+		}
+		c = &Client{
+			Client:    slack.New(s.Token),
+			Workspace: name,
+			cfg:       s,
+			limits:    l,
+			dir:       newDirectory(),
+		}
+		if len(cfg.SlackWorkspaceNames()) > 1 {
+			c.label = name
+		}
+		clients[name] = c
+	}
+	return c, nil
+}
+
+// SearchMessages searches the client's workspace. On an Enterprise Grid org
+// token, the search is scoped to the configured team.
+func (c *Client) SearchMessages(ctx context.Context, query string, params slack.SearchParameters) (*slack.SearchMessages, error) {
+	if params.TeamID == "" {
+		params.TeamID = c.cfg.TeamID
+	}
+	return call(ctx, c, "search.messages", func(ctx context.Context) (*slack.SearchMessages, error) {
+		return c.Client.SearchMessagesContext(ctx, query, params)
+	})
+}
+
+func (c *Client) GetConversationHistory(ctx context.Context, params *slack.GetConversationHistoryParameters) (*slack.GetConversationHistoryResponse, error) {
+	return call(ctx, c, "conversations.history", func(ctx context.Context) (*slack.GetConversationHistoryResponse, error) {
+		return c.Client.GetConversationHistoryContext(ctx, params)
+	})
+}
+
+// repliesPage is one page of conversations.replies.
+type repliesPage struct {
+	Messages   []slack.Message
+	HasMore    bool
+	NextCursor string
+}
+
+func (c *Client) GetConversationReplies(ctx context.Context, params *slack.GetConversationRepliesParameters) (repliesPage, error) {
+	return call(ctx, c, "conversations.replies", func(ctx context.Context) (repliesPage, error) {
+		msgs, hasMore, cursor, err := c.Client.GetConversationRepliesContext(ctx, params)
+		return repliesPage{Messages: msgs, HasMore: hasMore, NextCursor: cursor}, err
+	})
+}
+
+// channelsPage is one page of conversations.list.
+type channelsPage struct {
+	Channels   []slack.Channel
+	NextCursor string
+}
+
+func (c *Client) GetConversations(ctx context.Context, params *slack.GetConversationsParameters) (channelsPage, error) {
+	if params.TeamID == "" {
+		params.TeamID = c.cfg.TeamID
+	}
+	return call(ctx, c, "conversations.list", func(ctx context.Context) (channelsPage, error) {
+		channels, cursor, err := c.Client.GetConversationsContext(ctx, params)
+		return channelsPage{Channels: channels, NextCursor: cursor}, err
+	})
+}
+
+func (c *Client) GetConversationsForUser(ctx context.Context, params *slack.GetConversationsForUserParameters) (channelsPage, error) {
+	if params.TeamID == "" {
+		params.TeamID = c.cfg.TeamID
+	}
+	return call(ctx, c, "users.conversations", func(ctx context.Context) (channelsPage, error) {
+		channels, cursor, err := c.Client.GetConversationsForUserContext(ctx, params)
+		return channelsPage{Channels: channels, NextCursor: cursor}, err
+	})
+}
+
+// call runs fn once method's rate limit allows it, retrying rate limited and
+// transient failures with jittered exponential backoff until maxAttempts is
+// reached or ctx is done.
+func call[T any](ctx context.Context, c *Client, method string, fn func(context.Context) (T, error)) (T, error) {
+	var zero T
+	b := c.bucket(method)
+	for attempt := 1; ; attempt++ {
+		if err := b.wait(ctx); err != nil {
+			return zero, err
+		}
+
+		res, err := fn(ctx)
+		if err == nil {
+			return res, nil
+		}
+		if attempt == maxAttempts || ctx.Err() != nil {
+			return zero, err
+		}
+
+		var delay time.Duration
+		var rateErr *slack.RateLimitedError
+		switch {
+		case errors.As(err, &rateErr):
+			// Hold back every caller of this method, not just this one.
+			delay = rateErr.RetryAfter
+			b.pause(delay)
+		case classifyError(err) == errorNetwork && !writeMethods[method]:
+			delay = backoff(attempt)
+		default:
+			return zero, err
+		}
+
+		log.Printf("Slack %s failed (attempt %d/%d), retrying in %s: %v", method, attempt, maxAttempts, delay, err)
+		if err := sleep(ctx, delay); err != nil {
+			return zero, err
+		}
+	}
+}
+
+func (c *Client) bucket(method string) *bucket {
+	l := c.limits
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b, ok := l.buckets[method]
+	if !ok {
+		perMinute, ok := methodTiers[method]
+		if !ok {
+			perMinute = tier3
+		}
+		b = newBucket(perMinute)
+		l.buckets[method] = b
+	}
+	return b
+}
+
+// backoff returns a random delay up to baseBackoff*2^(attempt-1), capped at
+// maxBackoff ("full jitter").
+func backoff(attempt int) time.Duration {
+	d := min(baseBackoff<<(attempt-1), maxBackoff)
+	return time.Duration(rand.Int63n(int64(d))) + time.Millisecond
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// bucket is a token bucket refilled at a per-minute rate, allowing bursts of
+// up to ten seconds' worth of requests.
+type bucket struct {
+	mu       sync.Mutex
+	rate     float64 // tokens per second
+	capacity float64
+	tokens   float64
+	last     time.Time
+	until    time.Time // no tokens are handed out before this
+}
+
+func newBucket(perMinute int) *bucket {
+	capacity := max(float64(perMinute)/6, minRateBurst)
+	return &bucket{
+		rate:     float64(perMinute) / 60,
+		capacity: capacity,
+		tokens:   capacity,
+		last:     time.Now(),
+	}
+}
+
+// wait blocks until a token is available or ctx is done.
+func (b *bucket) wait(ctx context.Context) error {
+	for {
+		b.mu.Lock()
+		now := time.Now()
+		b.tokens = min(b.capacity, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+		b.last = now
+
+		var delay time.Duration
+		switch {
+		case now.Before(b.until):
+			delay = b.until.Sub(now)
+		case b.tokens >= 1:
+			b.tokens--
+			b.mu.Unlock()
+			return nil
+		default:
+			delay = time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+		}
+		b.mu.Unlock()
+
+		if err := sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// pause stops handing out tokens for d, as asked by Slack's Retry-After.
+func (b *bucket) pause(d time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if until := time.Now().Add(d); until.After(b.until) {
+		b.until = until
+	}
+	b.tokens = 0
+}
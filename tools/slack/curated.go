@@ -0,0 +1,272 @@
+package slack
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/slack-go/slack"
+)
+
+// maxContextChannels bounds how many channels of a search get their pins and
+// bookmarks looked up.
+const maxContextChannels = 5
+
+// contextTTL is how long the pins and bookmarks of a channel looked up for one
+// search are reused by the next ones, so that repeated searches in the same
+// channels don't spend the tier 2 allowance of pins.list.
+const contextTTL = 10 * time.Minute
+
+type cachedContext struct {
+	cc      ChannelContext
+	fetched time.Time
+}
+
+// ChannelContext is the curated knowledge of a channel: what its members
+// pinned, bookmarked and wrote in its canvas.
+type ChannelContext struct {
+	ChannelID   string     `json:"channel_id"`
+	Channel     string     `json:"channel"`
+	Pins        []Message  `json:"pins,omitempty"`
+	PinnedFiles []File     `json:"pinned_files,omitempty"`
+	Bookmarks   []Bookmark `json:"bookmarks,omitempty"`
+	Canvas      *File      `json:"canvas,omitempty"`
+}
+
+// Bookmark is a link bookmarked at the top of a channel.
+type Bookmark struct {
+	Title string `json:"title"`
+	Link  string `json:"link"`
+	Type  string `json:"type"`
+}
+
+func (c *Client) ListPins(ctx context.Context, channelID string) ([]slack.Item, error) {
+	return call(ctx, c, "pins.list", func(ctx context.Context) ([]slack.Item, error) {
+		items, _, err := c.Client.ListPinsContext(ctx, channelID)
+		return items, err
+	})
+}
+
+func (c *Client) ListBookmarks(ctx context.Context, channelID string) ([]slack.Bookmark, error) {
+	return call(ctx, c, "bookmarks.list", func(ctx context.Context) ([]slack.Bookmark, error) {
+		return c.Client.ListBookmarksContext(ctx, channelID)
+	})
+}
+
+// channelContext fetches the pins and bookmarks of a channel, and its canvas
+// too when withCanvas is set. Each part that can't be fetched is left out and
+// the first error is returned alongside.
+func channelContext(ctx context.Context, api *Client, channelID, channelName string, withCanvas bool) (ChannelContext, error) {
+	cc := ChannelContext{ChannelID: channelID, Channel: channelName}
+	var firstErr error
+	fail := func(what string, err error) {
+		log.Printf("Unable to fetch the %s of Slack channel %s: %v", what, channelID, err)
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	items, err := api.ListPins(ctx, channelID)
+	if err != nil {
+		fail("pins", err)
+	}
+	base := api.workspaceURL(ctx)
+	for _, item := range items {
+		switch {
+		case item.Message != nil:
+			cc.Pins = append(cc.Pins, pinnedMessage(ctx, api, base, channelID, channelName, *item.Message))
+		case item.File != nil:
+			cc.PinnedFiles = append(cc.PinnedFiles, newFile(*item.File))
+		}
+	}
+
+	bookmarks, err := api.ListBookmarks(ctx, channelID)
+	if err != nil {
+		fail("bookmarks", err)
+	}
+	sort.SliceStable(bookmarks, func(i, j int) bool { return bookmarks[i].Rank < bookmarks[j].Rank })
+	for _, b := range bookmarks {
+		cc.Bookmarks = append(cc.Bookmarks, Bookmark{Title: b.Title, Link: b.Link, Type: b.Type})
+	}
+
+	if withCanvas {
+		ch, err := api.GetConversationInfo(ctx, &slack.GetConversationInfoInput{ChannelID: channelID})
+		if err != nil {
+			fail("canvas", err)
+		} else if ch.Properties != nil && ch.Properties.Canvas.FileId != "" && !ch.Properties.Canvas.IsEmpty {
+			canvas := File{ID: ch.Properties.Canvas.FileId, Name: "Channel canvas", Type: "Canvas"}
+			readFile(ctx, api, &canvas)
+			cc.Canvas = &canvas
+		}
+	}
+	return cc, firstErr
+}
+
+// pinnedMessage converts a message from pins.list.
+func pinnedMessage(ctx context.Context, api *Client, base, channelID, channelName string, m slack.Message) Message {
+	permalink := m.Permalink
+	if permalink == "" {
+		permalink = messagePermalink(base, channelID, m.Timestamp, m.ThreadTimestamp)
+	}
+	g := &threadGroup{
+		Key: threadKey{ChannelID: channelID, ThreadTs: m.Timestamp},
+		Matches: []slack.SearchMessage{{
+			Channel:   slack.CtxChannel{ID: channelID, Name: channelName},
+			Timestamp: m.Timestamp,
+			Permalink: permalink,
+		}},
+	}
+	msg := newThread(ctx, api, g, []slack.Message{m}).Messages[0]
+	msg.Matched = false
+	msg.Pinned = true
+	return msg
+}
+
+// searchChannelContexts fetches the pins and bookmarks of the channels of
+// threads, best ranked channels first, and marks the threads that hold a
+// pinned or bookmarked message.
+func searchChannelContexts(ctx context.Context, api *Client, threads []Thread) []ChannelContext {
+	byRank := make([]Thread, len(threads))
+	copy(byRank, threads)
+	sort.SliceStable(byRank, func(i, j int) bool { return byRank[i].searchRank < byRank[j].searchRank })
+
+	var contexts []ChannelContext
+	seen := map[string]bool{}
+	for _, t := range byRank {
+		if seen[t.ChannelID] || len(contexts) == maxContextChannels {
+			continue
+		}
+		seen[t.ChannelID] = true
+		contexts = append(contexts, api.searchContext(ctx, t.ChannelID, t.Channel))
+	}
+
+	curated := map[string]bool{}
+	for _, cc := range contexts {
+		for _, m := range cc.Pins {
+			curated[cc.ChannelID+"/"+m.Timestamp] = true
+		}
+		for _, b := range cc.Bookmarks {
+			if target, err := parsePermalink(b.Link); err == nil {
+				curated[target.ChannelID+"/"+target.Timestamp] = true
+			}
+		}
+	}
+	for i := range threads {
+		for j := range threads[i].Messages {
+			m := &threads[i].Messages[j]
+			if m.Pinned || curated[m.ChannelID+"/"+m.Timestamp] {
+				threads[i].Curated = true
+			}
+		}
+	}
+	return contexts
+}
+
+// searchContext returns the pins and bookmarks of a channel for a search,
+// fetching them again once the cached ones are older than contextTTL. A
+// context that couldn't be fetched completely isn't cached.
+func (c *Client) searchContext(ctx context.Context, channelID, channelName string) ChannelContext {
+	c.dir.mu.Lock()
+	cached, ok := c.dir.contexts[channelID]
+	c.dir.mu.Unlock()
+	if ok && time.Since(cached.fetched) < contextTTL {
+		return cached.cc
+	}
+
+	cc, err := channelContext(ctx, c, channelID, channelName, false)
+	if err == nil {
+		c.dir.mu.Lock()
+		c.dir.contexts[channelID] = cachedContext{cc: cc, fetched: time.Now()}
+		c.dir.mu.Unlock()
+	}
+	return cc
+}
+
+// relevantContexts keeps the parts of contexts worth showing next to the
+// search results for query: bookmarks of the channels in threads, and their
+// pins that share a word with the query.
+func relevantContexts(query string, contexts []ChannelContext, threads []Thread) []ChannelContext {
+	inResults := map[string]bool{}
+	for _, t := range threads {
+		inResults[t.ChannelID] = true
+	}
+	terms := map[string]bool{}
+	for _, term := range tokenize(query) {
+		terms[term] = true
+	}
+
+	var relevant []ChannelContext
+	for _, cc := range contexts {
+		if !inResults[cc.ChannelID] {
+			continue
+		}
+		var pins []Message
+		for _, m := range cc.Pins {
+			for _, word := range tokenize(m.Text) {
+				if terms[word] {
+					pins = append(pins, m)
+					break
+				}
+			}
+		}
+		cc.Pins = pins
+		cc.PinnedFiles = nil
+		if len(cc.Pins) > 0 || len(cc.Bookmarks) > 0 {
+			relevant = append(relevant, cc)
+		}
+	}
+	return relevant
+}
+
+func GetChannelContextFromSlack(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	channel, _ := request.Params.Arguments["channel"].(string)
+	if channel == "" {
+		return mcp.NewToolResultError("A channel is required."), nil
+	}
+	api, errResult := clientForRequest(request)
+	if errResult != nil {
+		return errResult, nil
+	}
+	channelID, channelName, err := api.resolveChannel(ctx, channel)
+	if err != nil {
+		return toolError("find the Slack channel", err), nil
+	}
+
+	cc, err := channelContext(ctx, api, channelID, channelName, true)
+	if len(cc.Pins) == 0 && len(cc.PinnedFiles) == 0 && len(cc.Bookmarks) == 0 && cc.Canvas == nil {
+		if err != nil {
+			return toolError("read the pins and bookmarks of the Slack channel", err), nil
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("#%s has no pinned messages, bookmarks or canvas.", channelName)), nil
+	}
+
+	responseText := fmt.Sprintf("These are the messages, files and links the members of #%s pinned or bookmarked, and its canvas. They are curated, so treat them as the channel's most authoritative information.\n\n%s", channelName, renderChannelContexts([]ChannelContext{cc}))
+	if err != nil {
+		responseText += "\nSome of it could not be read. " + describeError(err)
+	}
+	return structuredResult(responseText, "slack://channel-context/"+channelID, cc), nil
+}
+
+func renderChannelContexts(contexts []ChannelContext) string {
+	var b strings.Builder
+	for _, cc := range contexts {
+		fmt.Fprintf(&b, "### Pinned and bookmarked in #%s\n", cc.Channel)
+		for _, m := range cc.Pins {
+			fmt.Fprintf(&b, "- Pinned [%s] **%s**: %s (%s)\n", m.Time, m.Author, m.Text, m.Permalink)
+			renderFiles(&b, "", m.Files)
+		}
+		renderFiles(&b, "", cc.PinnedFiles)
+		for _, bm := range cc.Bookmarks {
+			fmt.Fprintf(&b, "- Bookmark **%s**: %s\n", bm.Title, bm.Link)
+		}
+		if cc.Canvas != nil {
+			renderFiles(&b, "", []File{*cc.Canvas})
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
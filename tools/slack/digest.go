@@ -0,0 +1,272 @@
+package slack
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/slack-go/slack"
+)
+
+const (
+	// historyPageSize is how many messages are asked for per
+	// conversations.history call.
+	historyPageSize = 200
+	// maxDigestMessages bounds how many top-level messages a digest covers.
+	maxDigestMessages = 1000
+	// maxDigestThreads bounds how many threads a digest expands, the ones
+	// with the most replies first, so that conversations.replies (tier 3)
+	// doesn't keep the tool call waiting for minutes.
+	maxDigestThreads = 30
+	// defaultDigestDays is the time range of a digest when none is given.
+	defaultDigestDays = 7
+)
+
+var (
+	linkPattern = regexp.MustCompile(`https?://[^\s<>()\[\]|]+`)
+	// decisionPattern flags messages that record a decision.
+	decisionPattern = regexp.MustCompile(`(?i)\b(decided|decision|agreed|approved|we will|we'll go with|going with|let's go with|resolved|final call|signed off)\b`)
+)
+
+// Digest is the chronological summary of a channel over a time range.
+type Digest struct {
+	ChannelID string `json:"channel_id"`
+	Channel   string `json:"channel"`
+	Since     string `json:"since"`
+	Until     string `json:"until"`
+	Truncated bool   `json:"truncated,omitempty"`
+	// ThreadsTruncated is set when some threads weren't expanded; they only
+	// hold their first message.
+	ThreadsTruncated bool      `json:"threads_truncated,omitempty"`
+	Threads          []Thread  `json:"threads"`
+	Decisions        []Message `json:"decisions"`
+	Questions        []Message `json:"open_questions"`
+	Links            []string  `json:"links"`
+}
+
+func GetChannelDigestFromSlack(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	channel, _ := request.Params.Arguments["channel"].(string)
+	if channel == "" {
+		return mcp.NewToolResultError("A channel is required."), nil
+	}
+	until := time.Now()
+	since := until.AddDate(0, 0, -defaultDigestDays)
+	var err error
+	if s, _ := request.Params.Arguments["since"].(string); s != "" {
+		if since, err = parseDigestTime(s, false); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+	}
+	if s, _ := request.Params.Arguments["until"].(string); s != "" {
+		if until, err = parseDigestTime(s, true); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+	}
+	if !since.Before(until) {
+		return mcp.NewToolResultError("since must be before until."), nil
+	}
+
+	api, errResult := clientForRequest(request)
+	if errResult != nil {
+		return errResult, nil
+	}
+	channelID, channelName, err := api.resolveChannel(ctx, channel)
+	if err != nil {
+		return toolError("find the Slack channel", err), nil
+	}
+
+	digest, err := buildDigest(ctx, api, channelID, channelName, since, until)
+	if err != nil {
+		return toolError("read the Slack channel history", err), nil
+	}
+	if len(digest.Threads) == 0 {
+		return mcp.NewToolResultText(fmt.Sprintf("No messages were posted in #%s between %s and %s.", channelName, digest.Since, digest.Until)), nil
+	}
+
+	responseText := fmt.Sprintf("Write a digest of what happened in #%s between %s and %s from ONLY the below conversations, in chronological order. Call out the key decisions and open questions, and keep the links. Do NOT add any additional information.\n\n%s", channelName, digest.Since, digest.Until, renderDigest(digest))
+	return structuredResult(responseText, "slack://digest/"+channelID, digest), nil
+}
+
+// parseDigestTime accepts a date (YYYY-MM-DD) or an RFC 3339 timestamp. With
+// endOfDay, a date stands for the end of that day rather than its start, so
+// that it includes the day when it ends the range.
+func parseDigestTime(s string, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(searchDateLayout, s); err == nil {
+		if endOfDay {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q, expected YYYY-MM-DD or RFC 3339", s)
+}
+
+// buildDigest pages through the channel history between since and until,
+// expands the threads with the most replies and picks out decisions, open
+// questions and links.
+func buildDigest(ctx context.Context, api *Client, channelID, channelName string, since, until time.Time) (Digest, error) {
+	digest := Digest{
+		ChannelID: channelID,
+		Channel:   channelName,
+		Since:     since.UTC().Format(time.RFC3339),
+		Until:     until.UTC().Format(time.RFC3339),
+	}
+
+	roots, truncated, err := fetchHistory(ctx, api, channelID, slackTimestamp(since), slackTimestamp(until), maxDigestMessages)
+	if err != nil {
+		return digest, err
+	}
+	digest.Truncated = truncated
+
+	// History is newest first; a digest reads oldest first.
+	sort.Slice(roots, func(i, j int) bool { return roots[i].Timestamp < roots[j].Timestamp })
+
+	base := api.workspaceURL(ctx)
+	var groups []*threadGroup
+	for i, m := range roots {
+		match := slack.SearchMessage{
+			Channel:   slack.CtxChannel{ID: channelID, Name: channelName},
+			Timestamp: m.Timestamp,
+			Permalink: messagePermalink(base, channelID, m.Timestamp, ""),
+		}
+		g := &threadGroup{
+			Key:        threadKey{ChannelID: channelID, ThreadTs: m.Timestamp},
+			Matches:    []slack.SearchMessage{match},
+			SearchRank: i,
+		}
+		if m.ReplyCount == 0 {
+			g.Messages = []slack.Message{m}
+		}
+		groups = append(groups, g)
+	}
+
+	var threaded []*threadGroup
+	for _, g := range groups {
+		if g.Messages == nil {
+			threaded = append(threaded, g)
+		}
+	}
+	if len(threaded) > maxDigestThreads {
+		sort.SliceStable(threaded, func(i, j int) bool {
+			return roots[threaded[i].SearchRank].ReplyCount > roots[threaded[j].SearchRank].ReplyCount
+		})
+		for _, g := range threaded[maxDigestThreads:] {
+			g.Messages = []slack.Message{roots[g.SearchRank]}
+		}
+		threaded = threaded[:maxDigestThreads]
+		digest.ThreadsTruncated = true
+	}
+	expandThreads(ctx, threaded, func(ctx context.Context, g *threadGroup) ([]slack.Message, error) {
+		return fetchThreadMessages(ctx, api, g.Key.ChannelID, g.Key.ThreadTs)
+	})
+
+	seenLinks := map[string]bool{}
+	for i, g := range groups {
+		messages := g.Messages
+		if g.Err != nil {
+			log.Printf("Unable to expand Slack thread %s/%s: %v", g.Key.ChannelID, g.Key.ThreadTs, g.Err)
+			messages = []slack.Message{roots[i]}
+		}
+		thread := newThread(ctx, api, g, messages)
+		for j := range thread.Messages {
+			// Every message is part of the digest; none is a search hit.
+			thread.Messages[j].Matched = false
+		}
+		if len(messages) == 1 {
+			thread.MoreReplies = roots[i].ReplyCount
+		}
+		digest.Threads = append(digest.Threads, thread)
+
+		for j, m := range thread.Messages {
+			if decisionPattern.MatchString(m.Text) {
+				digest.Decisions = append(digest.Decisions, m)
+			}
+			// A question nobody replied to after it was asked is still open.
+			if isQuestion(m.Text) && j == len(thread.Messages)-1 && thread.MoreReplies == 0 {
+				digest.Questions = append(digest.Questions, m)
+			}
+			for _, link := range linkPattern.FindAllString(m.Text, -1) {
+				if !seenLinks[link] {
+					seenLinks[link] = true
+					digest.Links = append(digest.Links, link)
+				}
+			}
+		}
+	}
+	return digest, nil
+}
+
+// fetchHistory pages through the top-level messages of a channel between the
+// Slack timestamps oldest and latest, newest first, stopping after limit.
+func fetchHistory(ctx context.Context, api *Client, channelID string, oldest, latest string, limit int) ([]slack.Message, bool, error) {
+	params := &slack.GetConversationHistoryParameters{
+		ChannelID: channelID,
+		Oldest:    oldest,
+		Latest:    latest,
+		Limit:     historyPageSize,
+	}
+
+	var messages []slack.Message
+	for {
+		history, err := api.GetConversationHistory(ctx, params)
+		if err != nil {
+			return messages, false, err
+		}
+		for _, m := range history.Messages {
+			if m.SubType == "channel_join" || m.SubType == "channel_leave" {
+				continue
+			}
+			messages = append(messages, m)
+		}
+		if len(messages) >= limit {
+			return messages[:limit], true, nil
+		}
+		if !history.HasMore || history.ResponseMetaData.NextCursor == "" {
+			return messages, false, nil
+		}
+		params.Cursor = history.ResponseMetaData.NextCursor
+	}
+}
+
+func isQuestion(text string) bool {
+	return strings.HasSuffix(strings.TrimSpace(text), "?")
+}
+
+func renderDigest(d Digest) string {
+	var b strings.Builder
+	b.WriteString(renderThreads(d.Threads))
+	if d.Truncated {
+		fmt.Fprintf(&b, "Only the newest %d messages of the range are included.\n\n", maxDigestMessages)
+	}
+	if d.ThreadsTruncated {
+		fmt.Fprintf(&b, "Only the %d threads with the most replies were expanded; the others show their first message and how many replies they have.\n\n", maxDigestThreads)
+	}
+	if len(d.Decisions) > 0 {
+		b.WriteString("Possible decisions:\n")
+		for _, m := range d.Decisions {
+			fmt.Fprintf(&b, "- [%s] %s: %s (%s)\n", m.Time, m.Author, m.Text, m.Permalink)
+		}
+		b.WriteString("\n")
+	}
+	if len(d.Questions) > 0 {
+		b.WriteString("Unanswered questions:\n")
+		for _, m := range d.Questions {
+			fmt.Fprintf(&b, "- [%s] %s: %s (%s)\n", m.Time, m.Author, m.Text, m.Permalink)
+		}
+		b.WriteString("\n")
+	}
+	if len(d.Links) > 0 {
+		b.WriteString("Links shared:\n")
+		for _, l := range d.Links {
+			fmt.Fprintf(&b, "- %s\n", l)
+		}
+	}
+	return b.String()
+}
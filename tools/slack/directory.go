@@ -0,0 +1,224 @@
+package slack
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// directory caches the users, channels and user groups looked up through a
+// Client for the lifetime of the process, so names are resolved once however
+// often they appear.
+type directory struct {
+	mu         sync.Mutex
+	users      map[string]*slack.User
+	channels   map[string]string
+	userGroups map[string]string // nil until loaded
+	teamURL    string
+	// contexts holds the pins and bookmarks looked up for searches, which
+	// unlike names go stale, so they are only kept for contextTTL.
+	contexts map[string]cachedContext
+	// members holds the channels a user token is a member of, listed at
+	// membersListed.
+	members       map[string]bool
+	membersListed time.Time
+}
+
+func newDirectory() directory {
+	return directory{
+		users:    map[string]*slack.User{},
+		channels: map[string]string{},
+		contexts: map[string]cachedContext{},
+	}
+}
+
+func (c *Client) GetUserInfo(ctx context.Context, userID string) (*slack.User, error) {
+	return call(ctx, c, "users.info", func(ctx context.Context) (*slack.User, error) {
+		return c.Client.GetUserInfoContext(ctx, userID)
+	})
+}
+
+// user returns the profile of userID, or nil if it can't be looked up.
+func (c *Client) user(ctx context.Context, userID string) *slack.User {
+	if userID == "" {
+		return nil
+	}
+	c.dir.mu.Lock()
+	u, ok := c.dir.users[userID]
+	c.dir.mu.Unlock()
+	if ok {
+		return u
+	}
+
+	u, err := c.GetUserInfo(ctx, userID)
+	if err != nil {
+		log.Printf("Unable to look up Slack user %s: %v", userID, err)
+		// Remember the failure too so a missing user isn't fetched again
+		// for every message.
+		u = nil
+	}
+	c.dir.mu.Lock()
+	c.dir.users[userID] = u
+	c.dir.mu.Unlock()
+	return u
+}
+
+// userName returns the name to show for userID: the display name, falling back
+// to the real name, the user name and finally the ID itself.
+func (c *Client) userName(ctx context.Context, userID string) string {
+	u := c.user(ctx, userID)
+	switch {
+	case u == nil:
+		return userID
+	case u.Profile.DisplayName != "":
+		return u.Profile.DisplayName
+	case u.RealName != "":
+		return u.RealName
+	default:
+		return u.Name
+	}
+}
+
+func (c *Client) GetConversationInfo(ctx context.Context, input *slack.GetConversationInfoInput) (*slack.Channel, error) {
+	return call(ctx, c, "conversations.info", func(ctx context.Context) (*slack.Channel, error) {
+		return c.Client.GetConversationInfoContext(ctx, input)
+	})
+}
+
+func (c *Client) GetUserGroups(ctx context.Context, options ...slack.GetUserGroupsOption) ([]slack.UserGroup, error) {
+	if c.cfg.TeamID != "" {
+		options = append(options, slack.GetUserGroupsOptionWithTeamID(c.cfg.TeamID))
+	}
+	return call(ctx, c, "usergroups.list", func(ctx context.Context) ([]slack.UserGroup, error) {
+		return c.Client.GetUserGroupsContext(ctx, options...)
+	})
+}
+
+// channelName returns the name of channelID, or the ID itself if it can't be
+// looked up.
+func (c *Client) channelName(ctx context.Context, channelID string) string {
+	c.dir.mu.Lock()
+	name, ok := c.dir.channels[channelID]
+	c.dir.mu.Unlock()
+	if ok {
+		return name
+	}
+
+	name = channelID
+	if ch, err := c.GetConversationInfo(ctx, &slack.GetConversationInfoInput{ChannelID: channelID}); err != nil {
+		log.Printf("Unable to look up Slack channel %s: %v", channelID, err)
+	} else if ch.Name != "" {
+		name = ch.Name
+	}
+	c.dir.mu.Lock()
+	c.dir.channels[channelID] = name
+	c.dir.mu.Unlock()
+	return name
+}
+
+// userGroupHandle returns the handle of user group groupID, or the ID itself
+// if it can't be looked up. All groups are listed on first use.
+func (c *Client) userGroupHandle(ctx context.Context, groupID string) string {
+	c.dir.mu.Lock()
+	groups := c.dir.userGroups
+	c.dir.mu.Unlock()
+
+	if groups == nil {
+		groups = map[string]string{}
+		list, err := c.GetUserGroups(ctx, slack.GetUserGroupsOptionIncludeDisabled(true))
+		if err != nil {
+			log.Printf("Unable to list Slack user groups: %v", err)
+		}
+		for _, g := range list {
+			groups[g.ID] = g.Handle
+		}
+		c.dir.mu.Lock()
+		c.dir.userGroups = groups
+		c.dir.mu.Unlock()
+	}
+
+	if handle, ok := groups[groupID]; ok && handle != "" {
+		return handle
+	}
+	return groupID
+}
+
+// channelIDPattern matches conversation IDs: public (C), private (G) and
+// direct message (D) channels.
+var channelIDPattern = regexp.MustCompile(`^[CGD][A-Z0-9]{6,}$`)
+
+// resolveChannel returns the ID and name of the channel given by ID or by
+// name, with or without the leading "#".
+func (c *Client) resolveChannel(ctx context.Context, channel string) (string, string, error) {
+	channel = strings.TrimPrefix(strings.TrimSpace(channel), "#")
+	if channelIDPattern.MatchString(channel) {
+		return channel, c.channelName(ctx, channel), nil
+	}
+
+	c.dir.mu.Lock()
+	for id, name := range c.dir.channels {
+		if name == channel {
+			c.dir.mu.Unlock()
+			return id, name, nil
+		}
+	}
+	c.dir.mu.Unlock()
+
+	channels, err := listChannels(ctx, c, channelFilter{Types: []string{"public_channel", "private_channel"}})
+	if err != nil {
+		return "", "", err
+	}
+	c.dir.mu.Lock()
+	defer c.dir.mu.Unlock()
+	for _, ch := range channels {
+		c.dir.channels[ch.ID] = ch.Name
+	}
+	for _, ch := range channels {
+		if ch.Name == channel {
+			return ch.ID, ch.Name, nil
+		}
+	}
+	return "", "", fmt.Errorf("channel #%s not found", channel)
+}
+
+// workspaceURL returns the "https://team.slack.com/" URL of the workspace the
+// token belongs to, or of the configured team of an Enterprise Grid org,
+// used to build permalinks.
+func (c *Client) workspaceURL(ctx context.Context) string {
+	c.dir.mu.Lock()
+	u := c.dir.teamURL
+	c.dir.mu.Unlock()
+	if u != "" {
+		return u
+	}
+
+	if c.cfg.TeamID != "" {
+		team, err := call(ctx, c, "team.info", func(ctx context.Context) (*slack.TeamInfo, error) {
+			return c.Client.GetOtherTeamInfoContext(ctx, c.cfg.TeamID)
+		})
+		if err != nil {
+			log.Printf("Unable to look up Slack workspace URL: %v", err)
+			return ""
+		}
+		u = "https://" + team.Domain + ".slack.com/"
+	} else {
+		resp, err := call(ctx, c, "auth.test", func(ctx context.Context) (*slack.AuthTestResponse, error) {
+			return c.Client.AuthTestContext(ctx)
+		})
+		if err != nil {
+			log.Printf("Unable to look up Slack workspace URL: %v", err)
+			return ""
+		}
+		u = resp.URL
+	}
+	c.dir.mu.Lock()
+	c.dir.teamURL = u
+	c.dir.mu.Unlock()
+	return u
+}
@@ -0,0 +1,94 @@
+package slack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/slack-go/slack"
+)
+
+// errorKind groups Slack failures by what the user has to do about them.
+type errorKind int
+
+const (
+	errorOther errorKind = iota
+	errorAuth
+	errorScope
+	errorRateLimit
+	errorNetwork
+)
+
+var errNoToken = errors.New("no Slack token configured")
+
+// classifyError maps an error returned by a Slack call to an errorKind.
+func classifyError(err error) errorKind {
+	if errors.Is(err, errNoToken) {
+		return errorAuth
+	}
+
+	var rateErr *slack.RateLimitedError
+	if errors.As(err, &rateErr) {
+		return errorRateLimit
+	}
+
+	var apiErr slack.SlackErrorResponse
+	if errors.As(err, &apiErr) {
+		switch apiErr.Err {
+		case "not_authed", "invalid_auth", "account_inactive", "token_revoked",
+			"token_expired", "not_allowed_token_type", "org_login_required", "two_factor_setup_required":
+			return errorAuth
+		case "missing_scope", "no_permission", "not_in_channel", "channel_not_found",
+			"access_denied", "ekm_access_denied", "team_access_not_granted":
+			return errorScope
+		case "ratelimited":
+			return errorRateLimit
+		case "fatal_error", "internal_error", "service_unavailable", "request_timeout":
+			return errorNetwork
+		}
+		return errorOther
+	}
+
+	var statusErr slack.StatusCodeError
+	if errors.As(err, &statusErr) {
+		if statusErr.Code == 429 {
+			return errorRateLimit
+		}
+		if statusErr.Code >= 500 {
+			return errorNetwork
+		}
+		return errorOther
+	}
+
+	var netErr net.Error
+	var urlErr *url.Error
+	if errors.As(err, &netErr) || errors.As(err, &urlErr) || errors.Is(err, context.DeadlineExceeded) {
+		return errorNetwork
+	}
+	return errorOther
+}
+
+// describeError explains err and what can be done about it, in words the model
+// can pass on to the user.
+func describeError(err error) string {
+	switch classifyError(err) {
+	case errorAuth:
+		return fmt.Sprintf("Slack rejected Beacon's credentials (%v). Ask the user to set a valid Slack token with `beacon auth slack set`, adding `-workspace <name>` for a named workspace.", err)
+	case errorScope:
+		return fmt.Sprintf("Beacon's Slack token is not allowed to do this (%v). Ask the user to add the missing scope to the Slack app and reinstall it, or to join the channel.", err)
+	case errorRateLimit:
+		return fmt.Sprintf("Slack is rate limiting Beacon (%v). Wait a minute and try again with a narrower query.", err)
+	case errorNetwork:
+		return fmt.Sprintf("Slack could not be reached (%v). Check the network connection or Slack's status page and try again.", err)
+	default:
+		return fmt.Sprintf("Slack returned an error: %v.", err)
+	}
+}
+
+// toolError turns a failed Slack call into an MCP tool error.
+func toolError(action string, err error) *mcp.CallToolResult {
+	return mcp.NewToolResultError(fmt.Sprintf("Unable to %s. %s", action, describeError(err)))
+}
@@ -0,0 +1,243 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/pranavbalakrishnan4100/beacon-mcp-server/config"
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+	"github.com/slack-go/slack/socketmode"
+)
+
+const (
+	// indexSaveInterval is how often the listener writes the index to disk.
+	indexSaveInterval = 30 * time.Second
+	// maxEventBytes bounds the body of an Events API request.
+	maxEventBytes = 1 << 20
+)
+
+// indexedSubTypes are the message subtypes that carry something worth
+// searching. Joins, topic changes and the like are left out.
+var indexedSubTypes = map[string]bool{
+	"":                 true,
+	"thread_broadcast": true,
+	"bot_message":      true,
+	"file_share":       true,
+	"me_message":       true,
+}
+
+// Listen consumes the Slack events of the named workspace, or of the first
+// configured one when workspace is empty, through Socket Mode or the Events
+// API, as configured, and keeps its local message index up to date until ctx
+// is done. Each workspace needs a listener of its own.
+func Listen(ctx context.Context, workspace string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	if workspace == "" {
+		workspace = cfg.SlackWorkspaceNames()[0]
+	}
+	s, err := cfg.SlackWorkspace(workspace)
+	if err != nil {
+		return err
+	}
+	if s.IndexFile == "" {
+		return errors.New("no slack.index_file configured")
+	}
+	idx, err := openIndex(s.IndexFile)
+	if err != nil {
+		return err
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		saveIndexPeriodically(ctx, idx)
+	}()
+	defer func() { <-done }()
+
+	// A bot can only search what is indexed, so catch up on what was said
+	// while nobody listened.
+	if s.BotToken() {
+		go func() {
+			if err := Backfill(ctx, workspace); err != nil {
+				log.Printf("Slack backfill incomplete: %v", err)
+			}
+		}()
+	}
+
+	switch ev := s.Events; ev.Mode {
+	case config.SlackEventsSocket:
+		return listenSocketMode(ctx, s, idx)
+	case config.SlackEventsHTTP:
+		return listenHTTP(ctx, ev, idx)
+	default:
+		return fmt.Errorf("unknown slack events mode %q", ev.Mode)
+	}
+}
+
+// saveIndexPeriodically writes idx every indexSaveInterval and once more when
+// ctx is done.
+func saveIndexPeriodically(ctx context.Context, idx *messageIndex) {
+	t := time.NewTicker(indexSaveInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			if err := idx.save(); err != nil {
+				log.Printf("Unable to save the Slack message index: %v", err)
+			}
+			return
+		}
+		if err := idx.save(); err != nil {
+			log.Printf("Unable to save the Slack message index: %v", err)
+		}
+	}
+}
+
+func listenSocketMode(ctx context.Context, cfg config.Slack, idx *messageIndex) error {
+	if cfg.Events.AppToken == "" {
+		return errors.New("Socket Mode needs an app-level token, set slack.events.app_token or SLACK_APP_TOKEN")
+	}
+	client := socketmode.New(slack.New(cfg.Token, slack.OptionAppLevelToken(cfg.Events.AppToken)))
+
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case evt := <-client.Events:
+				switch evt.Type {
+				case socketmode.EventTypeConnected:
+					log.Printf("Connected to Slack Socket Mode")
+				case socketmode.EventTypeConnectionError, socketmode.EventTypeInvalidAuth:
+					log.Printf("Slack Socket Mode %s: %v", evt.Type, evt.Data)
+				case socketmode.EventTypeEventsAPI:
+					if evt.Request != nil {
+						client.Ack(*evt.Request)
+					}
+					if e, ok := evt.Data.(slackevents.EventsAPIEvent); ok && e.Type == slackevents.CallbackEvent {
+						indexEvent(idx, e.InnerEvent.Data)
+					}
+				}
+			}
+		}
+	}()
+
+	err := client.RunContext(ctx)
+	if ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
+
+func listenHTTP(ctx context.Context, cfg config.SlackEvents, idx *messageIndex) error {
+	if cfg.SigningSecret == "" {
+		return errors.New("the Events API endpoint needs a signing secret, set slack.events.signing_secret or SLACK_SIGNING_SECRET")
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/slack/events", &eventsHandler{secret: cfg.SigningSecret, idx: idx})
+	srv := &http.Server{Addr: cfg.ListenAddr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+
+	log.Printf("Listening for Slack events on %s/slack/events", cfg.ListenAddr)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// eventsHandler receives Events API requests, rejecting those that aren't
+// signed with the app's signing secret or are more than five minutes old.
+type eventsHandler struct {
+	secret string
+	idx    *messageIndex
+}
+
+func (h *eventsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxEventBytes))
+	if err != nil {
+		http.Error(w, "unable to read body", http.StatusBadRequest)
+		return
+	}
+
+	sv, err := slack.NewSecretsVerifier(r.Header, h.secret)
+	if err == nil {
+		if _, err = sv.Write(body); err == nil {
+			err = sv.Ensure()
+		}
+	}
+	if err != nil {
+		log.Printf("Rejected Slack event request: %v", err)
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	e, err := slackevents.ParseEvent(json.RawMessage(body), slackevents.OptionNoVerifyToken())
+	if err != nil {
+		http.Error(w, "invalid event", http.StatusBadRequest)
+		return
+	}
+	switch e.Type {
+	case slackevents.URLVerification:
+		var challenge slackevents.ChallengeResponse
+		if err := json.Unmarshal(body, &challenge); err != nil {
+			http.Error(w, "invalid challenge", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write([]byte(challenge.Challenge))
+	case slackevents.CallbackEvent:
+		indexEvent(h.idx, e.InnerEvent.Data)
+	}
+}
+
+// indexEvent applies a Slack event to the index.
+func indexEvent(idx *messageIndex, data interface{}) {
+	switch ev := data.(type) {
+	case *slackevents.MessageEvent:
+		switch {
+		case ev.SubType == "message_changed" && ev.Message != nil:
+			idx.put(indexedMessage{
+				ChannelID: ev.Channel,
+				User:      ev.Message.User,
+				Username:  ev.Message.Username,
+				Text:      ev.Message.Text,
+				Timestamp: ev.Message.TimeStamp,
+				ThreadTs:  ev.Message.ThreadTimeStamp,
+			})
+		case ev.SubType == "message_deleted":
+			idx.remove(ev.Channel, ev.DeletedTimeStamp)
+		case indexedSubTypes[ev.SubType]:
+			idx.put(indexedMessage{
+				ChannelID: ev.Channel,
+				User:      ev.User,
+				Username:  ev.Username,
+				Text:      ev.Text,
+				Timestamp: ev.TimeStamp,
+				ThreadTs:  ev.ThreadTimeStamp,
+			})
+		}
+	case *slackevents.ChannelRenameEvent:
+		idx.setChannelName(ev.Channel.ID, ev.Channel.Name)
+	}
+}
@@ -0,0 +1,156 @@
+package slack
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/pranavbalakrishnan4100/beacon-mcp-server/tools/extract"
+	"github.com/slack-go/slack"
+)
+
+// maxFilesPerCall bounds how many attached files one tool call downloads.
+const maxFilesPerCall = 10
+
+// File is a file, snippet or canvas attached to a message.
+type File struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Title     string `json:"title,omitempty"`
+	Type      string `json:"type"`
+	MimeType  string `json:"mime_type,omitempty"`
+	Permalink string `json:"permalink,omitempty"`
+	// Text is the extracted content, for the files of matched messages that
+	// could be read.
+	Text      string `json:"text,omitempty"`
+	Truncated bool   `json:"truncated,omitempty"`
+	// Note says why there is no Text, e.g. that the file is too large.
+	Note string `json:"note,omitempty"`
+}
+
+func newFile(f slack.File) File {
+	return File{
+		ID:        f.ID,
+		Name:      f.Name,
+		Title:     f.Title,
+		Type:      f.PrettyType,
+		MimeType:  f.Mimetype,
+		Permalink: f.Permalink,
+	}
+}
+
+func (c *Client) GetFileInfo(ctx context.Context, fileID string) (*slack.File, error) {
+	return call(ctx, c, "files.info", func(ctx context.Context) (*slack.File, error) {
+		f, _, _, err := c.Client.GetFileInfoContext(ctx, fileID, 0, 0)
+		return f, err
+	})
+}
+
+// DownloadFile downloads up to maxBytes of a file's url_private_download with
+// the token's credentials.
+func (c *Client) DownloadFile(ctx context.Context, downloadURL string, maxBytes int64) (*extract.Buffer, error) {
+	return call(ctx, c, "files.download", func(ctx context.Context) (*extract.Buffer, error) {
+		b := &extract.Buffer{Max: maxBytes}
+		if err := c.Client.GetFileContext(ctx, downloadURL, b); err != nil && !extract.IsFull(err) {
+			return nil, err
+		}
+		return b, nil
+	})
+}
+
+// attachFileContents fills in the text of the files attached to the matched
+// messages of threads, up to maxFilesPerCall files, through the client of
+// each thread's workspace.
+func attachFileContents(ctx context.Context, threads []Thread) {
+	n := 0
+	for i := range threads {
+		for j := range threads[i].Messages {
+			m := &threads[i].Messages[j]
+			if !m.Matched {
+				continue
+			}
+			for k := range m.Files {
+				if n == maxFilesPerCall {
+					m.Files[k].Note = "not read, too many files in these results"
+					continue
+				}
+				n++
+				readFile(ctx, threads[i].api, &m.Files[k])
+			}
+		}
+	}
+}
+
+// readFile fetches the content of f, or sets its Note to why it can't be read.
+func readFile(ctx context.Context, api *Client, f *File) {
+	cfg := api.cfg
+	info, err := api.GetFileInfo(ctx, f.ID)
+	if err != nil {
+		log.Printf("Unable to look up Slack file %s: %v", f.ID, err)
+		f.Note = "could not be looked up: " + describeError(err)
+		return
+	}
+	mimeType := info.Mimetype
+	switch {
+	case info.IsExternal:
+		f.Note = fmt.Sprintf("stored in %s, only linked from Slack", info.ExternalType)
+		return
+	case info.Mode == "hidden_by_limit":
+		f.Note = "hidden by the workspace's plan limits"
+		return
+	case info.Filetype == "quip" || info.Filetype == "canvas":
+		// Canvases download as HTML.
+		mimeType = "text/html"
+	case strings.HasPrefix(mimeType, "image/"):
+		// Slack doesn't hand out image descriptions through files.info, so
+		// the title is all there is.
+		f.Note = "an image, only its title is known"
+		return
+	}
+	if !extract.Supported(mimeType) {
+		f.Note = "content can't be read from this file type"
+		return
+	}
+	if mimeType == "application/pdf" && int64(info.Size) > cfg.MaxFileBytes {
+		f.Note = fmt.Sprintf("too large to read (%d bytes)", info.Size)
+		return
+	}
+
+	data, err := api.DownloadFile(ctx, info.URLPrivateDownload, cfg.MaxFileBytes)
+	if err != nil {
+		log.Printf("Unable to download Slack file %s: %v", f.ID, err)
+		f.Note = "could not be downloaded: " + describeError(err)
+		return
+	}
+	text, cut, err := extract.Text(ctx, data.Bytes(), mimeType, cfg.MaxFileChars)
+	if err != nil {
+		log.Printf("Unable to extract the text of Slack file %s: %v", f.ID, err)
+		f.Note = "content could not be extracted"
+		return
+	}
+	f.Text = text
+	f.Truncated = cut || data.Truncated
+}
+
+// renderFiles formats the files of a message below it.
+func renderFiles(b *strings.Builder, indent string, files []File) {
+	for _, f := range files {
+		name := f.Title
+		if name == "" {
+			name = f.Name
+		}
+		fmt.Fprintf(b, "%s  - Attached %s **%s** (%s)", indent, f.Type, name, f.Permalink)
+		switch {
+		case f.Note != "":
+			fmt.Fprintf(b, ": %s\n", f.Note)
+		case f.Text != "":
+			fmt.Fprintf(b, ":\n%s    ```\n%s    %s\n%s    ```\n", indent, indent, strings.ReplaceAll(f.Text, "\n", "\n"+indent+"    "), indent)
+			if f.Truncated {
+				fmt.Fprintf(b, "%s    (content cut short)\n", indent)
+			}
+		default:
+			b.WriteString("\n")
+		}
+	}
+}
@@ -0,0 +1,580 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pranavbalakrishnan4100/beacon-mcp-server/config"
+	"github.com/pranavbalakrishnan4100/beacon-mcp-server/tools/filelock"
+	"github.com/slack-go/slack"
+)
+
+// maxIndexMessages bounds the local index; the oldest messages are dropped
+// first.
+const maxIndexMessages = 100000
+
+// indexedMessage is a message as kept in the local index.
+type indexedMessage struct {
+	ChannelID string `json:"channel_id"`
+	User      string `json:"user,omitempty"`
+	Username  string `json:"username,omitempty"`
+	Text      string `json:"text"`
+	Timestamp string `json:"ts"`
+	ThreadTs  string `json:"thread_ts,omitempty"`
+
+	tokens []string
+}
+
+// messageIndex is the local message index: every message seen through Slack
+// events, kept in a JSON file so that the MCP server can search what `beacon
+// listen` collected. It is safe for concurrent use.
+type messageIndex struct {
+	path string
+
+	mu       sync.RWMutex
+	modTime  time.Time // of the file when it was last loaded or saved
+	channels map[string]string
+	messages map[string]*indexedMessage
+	// backfilled maps channel IDs to the time up to which their history
+	// was paged in.
+	backfilled map[string]string
+	// pending maps channel IDs to the backfill that is paging backwards
+	// through their history over several runs.
+	pending map[string]backfillProgress
+	// removed holds the keys of the messages removed since the index was
+	// last saved, so merging the file doesn't bring them back.
+	removed map[string]bool
+	dirty   bool
+}
+
+// backfillProgress is a backfill of a channel that reached its message limit
+// and continues, in the next run, with the messages before Oldest. Once it
+// reaches the start of the range, the channel is backfilled until Until.
+type backfillProgress struct {
+	Until  string `json:"until"`
+	Oldest string `json:"oldest"`
+}
+
+// indexFile is the on-disk form of a messageIndex.
+type indexFile struct {
+	Channels   map[string]string           `json:"channels"`
+	Backfilled map[string]string           `json:"backfilled,omitempty"`
+	Pending    map[string]backfillProgress `json:"pending,omitempty"`
+	Messages   []*indexedMessage           `json:"messages"`
+}
+
+var (
+	indexesMu sync.Mutex
+	indexes   = map[string]*messageIndex{}
+)
+
+// openIndex returns the shared index kept in path, loading it on first use.
+// A missing file yields an empty index.
+func openIndex(path string) (*messageIndex, error) {
+	indexesMu.Lock()
+	defer indexesMu.Unlock()
+	if idx, ok := indexes[path]; ok {
+		return idx, nil
+	}
+	idx := &messageIndex{
+		path:       path,
+		channels:   map[string]string{},
+		messages:   map[string]*indexedMessage{},
+		backfilled: map[string]string{},
+		pending:    map[string]backfillProgress{},
+		removed:    map[string]bool{},
+	}
+	if err := idx.reload(); err != nil {
+		return nil, err
+	}
+	indexes[path] = idx
+	return idx, nil
+}
+
+func indexKey(channelID, ts string) string {
+	return channelID + "/" + ts
+}
+
+// reload reads the index file again if it changed since it was last read,
+// e.g. because `beacon listen` runs in another process. Changes not saved yet
+// are merged with the file's rather than dropped.
+func (idx *messageIndex) reload() error {
+	st, err := os.Stat(idx.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("unable to read message index: %w", err)
+	}
+
+	idx.mu.RLock()
+	unchanged := st.ModTime().Equal(idx.modTime)
+	idx.mu.RUnlock()
+	if unchanged {
+		return nil
+	}
+
+	b, err := os.ReadFile(idx.path)
+	if err != nil {
+		return fmt.Errorf("unable to read message index: %w", err)
+	}
+	var f indexFile
+	if err := json.Unmarshal(b, &f); err != nil {
+		return fmt.Errorf("unable to parse message index: %w", err)
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if idx.dirty {
+		idx.mergeLocked(f)
+	} else {
+		idx.replaceLocked(f)
+	}
+	idx.modTime = st.ModTime()
+	return nil
+}
+
+// replaceLocked makes the index hold what f holds.
+func (idx *messageIndex) replaceLocked(f indexFile) {
+	idx.messages = make(map[string]*indexedMessage, len(f.Messages))
+	for _, m := range f.Messages {
+		m.tokens = tokenize(m.Text)
+		idx.messages[indexKey(m.ChannelID, m.Timestamp)] = m
+	}
+	idx.channels = f.Channels
+	if idx.channels == nil {
+		idx.channels = map[string]string{}
+	}
+	idx.backfilled = f.Backfilled
+	if idx.backfilled == nil {
+		idx.backfilled = map[string]string{}
+	}
+	idx.pending = f.Pending
+	if idx.pending == nil {
+		idx.pending = map[string]backfillProgress{}
+	}
+	idx.removed = map[string]bool{}
+	idx.dirty = false
+}
+
+// mergeLocked adds what another process saved in f to the changes of this
+// one: messages it hasn't removed, channels it doesn't know, and the later
+// backfill of each channel.
+func (idx *messageIndex) mergeLocked(f indexFile) {
+	for _, m := range f.Messages {
+		key := indexKey(m.ChannelID, m.Timestamp)
+		if _, ok := idx.messages[key]; ok || idx.removed[key] {
+			continue
+		}
+		m.tokens = tokenize(m.Text)
+		idx.messages[key] = m
+	}
+	for id, name := range f.Channels {
+		if _, ok := idx.channels[id]; !ok {
+			idx.channels[id] = name
+		}
+	}
+	for id, ts := range f.Backfilled {
+		if ts > idx.backfilled[id] {
+			idx.backfilled[id] = ts
+		}
+	}
+	for id, p := range f.Pending {
+		if _, ok := idx.pending[id]; !ok && p.Until > idx.backfilled[id] {
+			idx.pending[id] = p
+		}
+	}
+	for id, p := range idx.pending {
+		if p.Until <= idx.backfilled[id] {
+			delete(idx.pending, id)
+		}
+	}
+	if len(idx.messages) > maxIndexMessages {
+		idx.trimLocked()
+	}
+}
+
+// put adds or replaces a message.
+func (idx *messageIndex) put(m indexedMessage) {
+	m.tokens = tokenize(m.Text)
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.messages[indexKey(m.ChannelID, m.Timestamp)] = &m
+	idx.dirty = true
+	// Trim in batches rather than on every message.
+	if len(idx.messages) > maxIndexMessages+maxIndexMessages/10 {
+		idx.trimLocked()
+	}
+}
+
+// remove deletes a message.
+func (idx *messageIndex) remove(channelID, ts string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	key := indexKey(channelID, ts)
+	if _, ok := idx.messages[key]; ok {
+		delete(idx.messages, key)
+		idx.dirty = true
+	}
+	idx.removed[key] = true
+}
+
+// setChannelName records the name of a channel, e.g. after it was renamed.
+func (idx *messageIndex) setChannelName(channelID, name string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.channels[channelID] = name
+	idx.dirty = true
+}
+
+// backfilledUntil returns the timestamp up to which the history of channelID
+// was paged in, or "".
+func (idx *messageIndex) backfilledUntil(channelID string) string {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.backfilled[channelID]
+}
+
+// setBackfilled records that the history of channelID was paged in up to ts,
+// ending a backfill in progress.
+func (idx *messageIndex) setBackfilled(channelID, ts string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.backfilled[channelID] = ts
+	delete(idx.pending, channelID)
+	idx.dirty = true
+}
+
+// backfillInProgress returns the backfill of channelID that continues in the
+// next run, if any.
+func (idx *messageIndex) backfillInProgress(channelID string) (backfillProgress, bool) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	p, ok := idx.pending[channelID]
+	return p, ok
+}
+
+func (idx *messageIndex) setBackfillInProgress(channelID string, p backfillProgress) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.pending[channelID] = p
+	idx.dirty = true
+}
+
+// len returns how many messages the index holds.
+func (idx *messageIndex) len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.messages)
+}
+
+// trimLocked drops the oldest messages beyond maxIndexMessages.
+func (idx *messageIndex) trimLocked() {
+	all := make([]*indexedMessage, 0, len(idx.messages))
+	for _, m := range idx.messages {
+		all = append(all, m)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Timestamp > all[j].Timestamp })
+	for _, m := range all[maxIndexMessages:] {
+		delete(idx.messages, indexKey(m.ChannelID, m.Timestamp))
+	}
+}
+
+// save atomically writes the index if it changed, readable only by the
+// current user since it holds message contents. An exclusive lock on a
+// sibling ".lock" file keeps `beacon listen` and `beacon index` from
+// overwriting each other: what the other saved since the index was last read
+// is merged in first.
+func (idx *messageIndex) save() error {
+	idx.mu.RLock()
+	dirty := idx.dirty
+	idx.mu.RUnlock()
+	if !dirty {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(idx.path), 0700); err != nil {
+		return fmt.Errorf("unable to create message index dir: %w", err)
+	}
+	unlock, err := filelock.Lock(idx.path + ".lock")
+	if err != nil {
+		return fmt.Errorf("unable to lock message index: %w", err)
+	}
+	defer unlock()
+	if err := idx.reload(); err != nil {
+		return err
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	f := indexFile{Channels: idx.channels, Backfilled: idx.backfilled, Pending: idx.pending}
+	for _, m := range idx.messages {
+		f.Messages = append(f.Messages, m)
+	}
+	b, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(idx.path), filepath.Base(idx.path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("unable to save message index: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return fmt.Errorf("unable to save message index: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("unable to save message index: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), idx.path); err != nil {
+		return fmt.Errorf("unable to save message index: %w", err)
+	}
+
+	if st, err := os.Stat(idx.path); err == nil {
+		idx.modTime = st.ModTime()
+	}
+	idx.removed = map[string]bool{}
+	idx.dirty = false
+	return nil
+}
+
+// indexFilter restricts a search of the index like the search.messages
+// modifiers do.
+type indexFilter struct {
+	ChannelIDs map[string]bool // nil for every channel
+	After      time.Time
+	Before     time.Time
+}
+
+// search returns up to limit messages matching query, best first, in the form
+// of search.messages matches with permalinks under base. Messages must contain
+// at least one query term.
+func (idx *messageIndex) search(query string, f indexFilter, limit int, base string) []slack.SearchMessage {
+	terms := tokenize(query)
+	if len(terms) == 0 {
+		return nil
+	}
+	want := map[string]bool{}
+	for _, t := range terms {
+		want[t] = true
+	}
+
+	idx.mu.RLock()
+	var candidates []*indexedMessage
+	for _, m := range idx.messages {
+		if f.ChannelIDs != nil && !f.ChannelIDs[m.ChannelID] {
+			continue
+		}
+		if t, ok := parseTimestamp(m.Timestamp); ok && ((!f.After.IsZero() && !t.After(f.After)) || (!f.Before.IsZero() && !t.Before(f.Before))) {
+			continue
+		}
+		for _, tok := range m.tokens {
+			if want[tok] {
+				candidates = append(candidates, m)
+				break
+			}
+		}
+	}
+	channels := make(map[string]string, len(idx.channels))
+	for id, name := range idx.channels {
+		channels[id] = name
+	}
+	idx.mu.RUnlock()
+
+	docs := make([][]string, len(candidates))
+	for i, m := range candidates {
+		docs[i] = m.tokens
+	}
+	scores := bm25Scores(terms, docs)
+	order := make([]int, len(candidates))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		if scores[order[i]] != scores[order[j]] {
+			return scores[order[i]] > scores[order[j]]
+		}
+		return candidates[order[i]].Timestamp > candidates[order[j]].Timestamp
+	})
+	if len(order) > limit {
+		order = order[:limit]
+	}
+
+	matches := make([]slack.SearchMessage, 0, len(order))
+	for _, i := range order {
+		m := candidates[i]
+		matches = append(matches, slack.SearchMessage{
+			Type:      "message",
+			Channel:   slack.CtxChannel{ID: m.ChannelID, Name: channels[m.ChannelID]},
+			User:      m.User,
+			Username:  m.Username,
+			Timestamp: m.Timestamp,
+			Text:      m.Text,
+			Permalink: messagePermalink(base, m.ChannelID, m.Timestamp, m.ThreadTs),
+		})
+	}
+	return matches
+}
+
+// localIndex returns the configured message index, or nil when there is none
+// or it can't be read.
+func localIndex(path string) *messageIndex {
+	if path == "" {
+		return nil
+	}
+	idx, err := openIndex(path)
+	if err == nil {
+		err = idx.reload()
+	}
+	if err != nil {
+		log.Printf("Unable to use the local Slack message index: %v", err)
+		return nil
+	}
+	return idx
+}
+
+// memberChannelsTTL is how long the channels a user token is a member of are
+// remembered for filtering the index.
+const memberChannelsTTL = 10 * time.Minute
+
+// memberChannels returns the IDs of the public and private channels the token
+// is a member of, listing them again once the last list is older than
+// memberChannelsTTL.
+func (c *Client) memberChannels(ctx context.Context) (map[string]bool, error) {
+	c.dir.mu.Lock()
+	members, listed := c.dir.members, c.dir.membersListed
+	c.dir.mu.Unlock()
+	if members != nil && time.Since(listed) < memberChannelsTTL {
+		return members, nil
+	}
+
+	// users.conversations only lists the channels the token's user is in.
+	params := &slack.GetConversationsForUserParameters{
+		Limit: channelsPageSize,
+		Types: []string{"public_channel", "private_channel"},
+	}
+	members = map[string]bool{}
+	for {
+		page, err := c.GetConversationsForUser(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+		for _, ch := range page.Channels {
+			members[ch.ID] = true
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		params.Cursor = page.NextCursor
+	}
+	c.dir.mu.Lock()
+	c.dir.members, c.dir.membersListed = members, time.Now()
+	c.dir.mu.Unlock()
+	return members, nil
+}
+
+// indexMatches searches the local index for topic, leaving out the messages
+// search.messages already found. Filters the index can't apply, such as
+// authors, skip it altogether. The index holds the channels of the bot that
+// fills it, so with a user token only the channels the user is a member of
+// are searched.
+func indexMatches(ctx context.Context, api *Client, topic string, f searchFilters, limit int, found []slack.SearchMessage) []slack.SearchMessage {
+	if !f.indexable() {
+		return nil
+	}
+	idx := localIndex(api.cfg.IndexFile)
+	if idx == nil || idx.len() == 0 {
+		// Nothing was indexed, e.g. because `beacon listen` never ran.
+		return nil
+	}
+
+	var filter indexFilter
+	if len(f.Channels) > 0 {
+		filter.ChannelIDs = map[string]bool{}
+		for _, c := range f.Channels {
+			if id, _, err := api.resolveChannel(ctx, c); err == nil {
+				filter.ChannelIDs[id] = true
+			}
+		}
+	}
+	if !api.cfg.BotToken() {
+		members, err := api.memberChannels(ctx)
+		if err != nil {
+			log.Printf("Unable to list the Slack channels of the user, leaving out the local index: %v", err)
+			return nil
+		}
+		if filter.ChannelIDs == nil {
+			filter.ChannelIDs = members
+		} else {
+			for id := range filter.ChannelIDs {
+				if !members[id] {
+					delete(filter.ChannelIDs, id)
+				}
+			}
+		}
+	}
+	// Like the search modifiers, after: and before: exclude the day itself.
+	if t, err := time.Parse(searchDateLayout, f.After); err == nil {
+		filter.After = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+	if t, err := time.Parse(searchDateLayout, f.Before); err == nil {
+		filter.Before = t
+	}
+
+	seen := map[string]bool{}
+	for _, m := range found {
+		seen[indexKey(m.Channel.ID, m.Timestamp)] = true
+	}
+	var matches []slack.SearchMessage
+	for _, m := range idx.search(topic, filter, limit, api.workspaceURL(ctx)) {
+		if seen[indexKey(m.Channel.ID, m.Timestamp)] {
+			continue
+		}
+		if m.Channel.Name == "" {
+			m.Channel.Name = api.channelName(ctx, m.Channel.ID)
+		}
+		matches = append(matches, m)
+	}
+	return matches
+}
+
+// indexable reports whether the index can apply the filters.
+func (f searchFilters) indexable() bool {
+	return len(f.Authors) == 0 && !f.HasLink && !f.HasAttachment && !f.ThreadOnly
+}
+
+// pageMatches returns page number page (1-based) of matches, in pages of
+// limit, as searchMessages does.
+func pageMatches(matches []slack.SearchMessage, limit, page int) searchResult {
+	res := searchResult{Total: len(matches)}
+	start := min((page-1)*limit, len(matches))
+	end := min(start+limit, len(matches))
+	res.Matches = matches[start:end]
+	if end < len(matches) {
+		res.NextPage = page + 1
+	}
+	return res
+}
+
+// checkBotSearch explains, in words meant for the user, why a bot token can't
+// run a search, or returns nil.
+func checkBotSearch(cfg config.Slack, f searchFilters) error {
+	if !f.indexable() {
+		return errors.New("Beacon uses a Slack bot token, which can only filter searches by channel and date. Retry without the other filters.")
+	}
+	if idx := localIndex(cfg.IndexFile); idx == nil || idx.len() == 0 {
+		return errors.New("Beacon uses a Slack bot token, which can't search Slack directly, and its message index is empty. Ask the user to run `beacon index` or `beacon listen`, and to invite the bot to the channels to search.")
+	}
+	return nil
+}
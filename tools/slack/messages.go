@@ -0,0 +1,278 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/slack-go/slack"
+)
+
+// Message is a Slack message as handed to the model.
+type Message struct {
+	ChannelID string `json:"channel_id"`
+	Channel   string `json:"channel"`
+	AuthorID  string `json:"author_id,omitempty"`
+	Author    string `json:"author"`
+	Time      string `json:"time"`
+	Timestamp string `json:"ts"`
+	ThreadTs  string `json:"thread_ts,omitempty"`
+	// ThreadPosition is 0 for a thread root or standalone message and n for
+	// the nth reply.
+	ThreadPosition int    `json:"thread_position"`
+	Permalink      string `json:"permalink"`
+	Reactions      int    `json:"reactions"`
+	Text           string `json:"text"`
+	Files          []File `json:"files,omitempty"`
+	Pinned         bool   `json:"pinned,omitempty"`
+	// Matched is set on the messages the search itself returned, as opposed
+	// to the ones added for context.
+	Matched bool `json:"matched"`
+}
+
+// Thread is a conversation: a thread with its replies, or a standalone
+// message.
+type Thread struct {
+	// Workspace names the workspace the thread is in when several are
+	// configured.
+	Workspace string  `json:"workspace,omitempty"`
+	ChannelID string  `json:"channel_id"`
+	Channel   string  `json:"channel"`
+	ThreadTs  string  `json:"thread_ts"`
+	Permalink string  `json:"permalink"`
+	Score     float64 `json:"score"`
+	// Curated is set when a message of the thread is pinned or bookmarked
+	// in its channel.
+	Curated  bool      `json:"curated,omitempty"`
+	Messages []Message `json:"messages"`
+	// MoreReplies counts the replies that weren't fetched.
+	MoreReplies int `json:"more_replies,omitempty"`
+
+	// searchRank is the position of the thread's best match in Slack's own
+	// ordering.
+	searchRank int
+	// api is the client of the thread's workspace.
+	api *Client
+}
+
+// newThread converts the fetched messages of g into a Thread, resolving author
+// names through api.
+func newThread(ctx context.Context, api *Client, g *threadGroup, messages []slack.Message) Thread {
+	match := g.representative()
+	base := workspaceURL(match.Permalink)
+	matched := map[string]bool{}
+	for _, m := range g.Matches {
+		matched[m.Timestamp] = true
+	}
+
+	t := Thread{
+		Workspace:  api.label,
+		ChannelID:  g.Key.ChannelID,
+		Channel:    match.Channel.Name,
+		ThreadTs:   g.Key.ThreadTs,
+		Permalink:  messagePermalink(base, g.Key.ChannelID, g.Key.ThreadTs, ""),
+		searchRank: g.SearchRank,
+		api:        api,
+	}
+	isThread := len(messages) > 1 || (len(messages) == 1 && messages[0].ThreadTimestamp != "")
+	for i, m := range messages {
+		msg := Message{
+			ChannelID:      t.ChannelID,
+			Channel:        t.Channel,
+			AuthorID:       m.User,
+			Author:         authorName(ctx, api, m),
+			Time:           formatTimestamp(m.Timestamp),
+			Timestamp:      m.Timestamp,
+			ThreadPosition: i,
+			Permalink:      m.Permalink,
+			Reactions:      reactionCount(m.Reactions),
+			Text:           api.renderMrkdwn(ctx, m.Text),
+			Matched:        matched[m.Timestamp],
+			Pinned:         len(m.PinnedTo) > 0,
+		}
+		if isThread {
+			msg.ThreadTs = t.ThreadTs
+		}
+		if msg.Permalink == "" {
+			msg.Permalink = messagePermalink(base, t.ChannelID, m.Timestamp, msg.ThreadTs)
+		}
+		for _, f := range m.Files {
+			msg.Files = append(msg.Files, newFile(f))
+		}
+		t.Messages = append(t.Messages, msg)
+	}
+	return t
+}
+
+// matchesThread builds a Thread straight from the search matches of g, for
+// when its conversation could not be fetched. Search matches don't carry the
+// files attached to them, so each matched message is looked up on its own
+// for them, which can succeed when its whole thread couldn't be read.
+func matchesThread(ctx context.Context, api *Client, g *threadGroup) Thread {
+	match := g.representative()
+	t := Thread{
+		Workspace:  api.label,
+		ChannelID:  g.Key.ChannelID,
+		Channel:    match.Channel.Name,
+		ThreadTs:   g.Key.ThreadTs,
+		Permalink:  match.Permalink,
+		searchRank: g.SearchRank,
+		api:        api,
+	}
+	for _, m := range g.Matches {
+		msg := Message{
+			ChannelID: t.ChannelID,
+			Channel:   t.Channel,
+			AuthorID:  m.User,
+			Author:    m.Username,
+			Time:      formatTimestamp(m.Timestamp),
+			Timestamp: m.Timestamp,
+			Permalink: m.Permalink,
+			Text:      api.renderMrkdwn(ctx, m.Text),
+			Matched:   true,
+		}
+		if ctx.Err() == nil {
+			if full, err := fetchMessageByTimestamp(ctx, api, t.ChannelID, m.Timestamp); err == nil {
+				for _, f := range full.Files {
+					msg.Files = append(msg.Files, newFile(f))
+				}
+			}
+		}
+		t.Messages = append(t.Messages, msg)
+	}
+	return t
+}
+
+func authorName(ctx context.Context, api *Client, m slack.Message) string {
+	switch {
+	case m.User != "":
+		return api.userName(ctx, m.User)
+	case m.BotProfile != nil && m.BotProfile.Name != "":
+		return m.BotProfile.Name
+	default:
+		return m.Username
+	}
+}
+
+func reactionCount(reactions []slack.ItemReaction) int {
+	n := 0
+	for _, r := range reactions {
+		n += r.Count
+	}
+	return n
+}
+
+// workspaceURL returns the "https://team.slack.com/" prefix of a permalink.
+func workspaceURL(permalink string) string {
+	u, err := url.Parse(permalink)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	return u.Scheme + "://" + u.Host + "/"
+}
+
+// messagePermalink builds the permalink of a message the way Slack does; base
+// is the workspace URL. Replies carry their thread's timestamp.
+func messagePermalink(base, channelID, ts, threadTs string) string {
+	if base == "" {
+		return ""
+	}
+	link := fmt.Sprintf("%sarchives/%s/p%s", base, channelID, strings.Replace(ts, ".", "", 1))
+	if threadTs != "" && threadTs != ts {
+		link += fmt.Sprintf("?thread_ts=%s&cid=%s", threadTs, channelID)
+	}
+	return link
+}
+
+// parseTimestamp converts a Slack "seconds.micros" timestamp to a time.
+func parseTimestamp(ts string) (time.Time, bool) {
+	sec, frac, _ := strings.Cut(ts, ".")
+	s, err := strconv.ParseInt(sec, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	var micros int64
+	if frac != "" {
+		micros, _ = strconv.ParseInt(frac, 10, 64)
+	}
+	return time.Unix(s, micros*int64(time.Microsecond)).UTC(), true
+}
+
+// slackTimestamp converts a time to a Slack timestamp.
+func slackTimestamp(t time.Time) string {
+	return fmt.Sprintf("%d.%06d", t.Unix(), t.Nanosecond()/int(time.Microsecond))
+}
+
+func formatTimestamp(ts string) string {
+	t, ok := parseTimestamp(ts)
+	if !ok {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// renderThreads formats threads as Markdown, one section per conversation.
+func renderThreads(threads []Thread) string {
+	var b strings.Builder
+	for _, t := range threads {
+		channel := t.Channel
+		if channel == "" {
+			channel = t.ChannelID
+		}
+		channel = "#" + channel
+		if t.Workspace != "" {
+			channel += " of the " + t.Workspace + " workspace"
+		}
+		if len(t.Messages) > 1 {
+			fmt.Fprintf(&b, "### Thread in %s (%s)\n", channel, t.Permalink)
+		} else {
+			fmt.Fprintf(&b, "### Message in %s (%s)\n", channel, t.Permalink)
+		}
+		for _, m := range t.Messages {
+			indent := ""
+			if m.ThreadPosition > 0 {
+				indent = "  "
+			}
+			fmt.Fprintf(&b, "%s- [%s] **%s**: %s", indent, m.Time, m.Author, m.Text)
+			if m.Reactions > 0 {
+				fmt.Fprintf(&b, " (%d reactions)", m.Reactions)
+			}
+			b.WriteString("\n")
+			renderFiles(&b, indent, m.Files)
+		}
+		if t.MoreReplies > 0 {
+			fmt.Fprintf(&b, "  - (%d replies not shown)\n", t.MoreReplies)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// threadsResult returns text followed by the threads, both rendered for
+// reading and embedded as a JSON resource for clients that want the fields.
+func threadsResult(text string, threads []Thread, uri string) *mcp.CallToolResult {
+	return structuredResult(text, uri, struct {
+		Threads []Thread `json:"threads"`
+	}{threads})
+}
+
+// structuredResult returns a text result with v attached as an embedded JSON
+// resource.
+func structuredResult(text, uri string, v interface{}) *mcp.CallToolResult {
+	result := mcp.NewToolResultText(text)
+	data, err := json.Marshal(v)
+	if err != nil {
+		return result
+	}
+	result.Content = append(result.Content, mcp.NewEmbeddedResource(mcp.TextResourceContents{
+		URI:      uri,
+		MIMEType: "application/json",
+		Text:     string(data),
+	}))
+	return result
+}
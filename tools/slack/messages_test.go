@@ -0,0 +1,68 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/slack-go/slack"
+)
+
+func testFile() slack.File {
+	return slack.File{ID: "F1", Name: "runbook.md", Title: "Runbook", PrettyType: "Markdown", Mimetype: "text/markdown"}
+}
+
+func TestNewThreadKeepsFiles(t *testing.T) {
+	api := &Client{dir: newDirectory()}
+	msg := testMessage("100.000", "", 0)
+	msg.Files = []slack.File{testFile()}
+	g := &threadGroup{
+		Key:     threadKey{ChannelID: "C1", ThreadTs: "100.000"},
+		Matches: []slack.SearchMessage{testMatch("100.000", "")},
+	}
+
+	thread := newThread(context.Background(), api, g, []slack.Message{msg})
+	if len(thread.Messages) != 1 {
+		t.Fatalf("got %d messages, want 1", len(thread.Messages))
+	}
+	if files := thread.Messages[0].Files; len(files) != 1 || files[0].ID != "F1" || files[0].Name != "runbook.md" {
+		t.Errorf("files = %+v, want F1 runbook.md", files)
+	}
+}
+
+// A match whose thread can't be fetched keeps the files of its message, which
+// is looked up on its own.
+func TestMatchWithoutThreadKeepsFiles(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if r.Form.Get("oldest") == "" {
+			json.NewEncoder(w).Encode(map[string]any{"ok": false, "error": "thread_not_found"})
+			return
+		}
+		msg := testMessage("201.000", "200.000", 0)
+		msg.Files = []slack.File{testFile()}
+		json.NewEncoder(w).Encode(map[string]any{"ok": true, "messages": []slack.Message{msg}})
+	}))
+	defer srv.Close()
+	api := &Client{
+		Client: slack.New("xoxp-test", slack.OptionAPIURL(srv.URL+"/")),
+		limits: &rateLimits{buckets: map[string]*bucket{}},
+		dir:    newDirectory(),
+	}
+
+	threads, err := removeDuplicateMessages(context.Background(), api, []slack.SearchMessage{testMatch("201.000", "200.000")})
+	if err == nil {
+		t.Error("expected the error fetching the thread")
+	}
+	if len(threads) != 1 || len(threads[0].Messages) != 1 {
+		t.Fatalf("threads = %+v, want the match alone", threads)
+	}
+	if files := threads[0].Messages[0].Files; len(files) != 1 || files[0].ID != "F1" {
+		t.Errorf("files = %+v, want F1", files)
+	}
+}
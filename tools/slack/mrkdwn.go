@@ -0,0 +1,108 @@
+package slack
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+)
+
+var (
+	// codePattern matches code blocks and inline code, which are left as-is.
+	codePattern = regexp.MustCompile("(?s)```.*?```|`[^`\n]+`")
+	// controlPattern matches Slack's <...> control sequences: mentions,
+	// channel links, special commands and URLs.
+	controlPattern = regexp.MustCompile(`<([^<>\n]+)>`)
+
+	// Slack's _italics_ are already valid Markdown; bold and strikethrough
+	// use single markers where Markdown needs double ones. Either may be
+	// nested in the other or in italics, but not in itself, so that converted
+	// spans aren't matched again.
+	boldPattern   = regexp.MustCompile(`(^|[\s(\["'_~])\*([^*\n]+?)\*($|[\s.,;:!?)\]"'_~])`)
+	strikePattern = regexp.MustCompile(`(^|[\s(\["'_*])~([^~\n]+?)~($|[\s.,;:!?)\]"'_*])`)
+)
+
+// renderMrkdwn converts Slack mrkdwn to Markdown: mentions of users, channels
+// and user groups are resolved to names, links and formatting are converted
+// and HTML entities unescaped.
+func (c *Client) renderMrkdwn(ctx context.Context, text string) string {
+	var b strings.Builder
+	last := 0
+	for _, loc := range codePattern.FindAllStringIndex(text, -1) {
+		b.WriteString(c.renderMrkdwnSegment(ctx, text[last:loc[0]]))
+		b.WriteString(html.UnescapeString(text[loc[0]:loc[1]]))
+		last = loc[1]
+	}
+	b.WriteString(c.renderMrkdwnSegment(ctx, text[last:]))
+	return b.String()
+}
+
+// renderMrkdwnSegment renders text that contains no code.
+func (c *Client) renderMrkdwnSegment(ctx context.Context, text string) string {
+	// Swap control sequences for placeholders first so that formatting
+	// markers inside URLs or names are left alone.
+	var replacements []string
+	text = controlPattern.ReplaceAllStringFunc(text, func(m string) string {
+		replacements = append(replacements, c.renderControl(ctx, m[1:len(m)-1]))
+		return fmt.Sprintf("\x00%d\x00", len(replacements)-1)
+	})
+
+	text = replaceSpans(boldPattern, text, "$1**$2**$3")
+	text = replaceSpans(strikePattern, text, "$1~~$2~~$3")
+
+	// The control sequences are unescaped already, so only the text
+	// around them is.
+	text = html.UnescapeString(text)
+	for i, r := range replacements {
+		text = strings.Replace(text, fmt.Sprintf("\x00%d\x00", i), r, 1)
+	}
+	return text
+}
+
+// replaceSpans converts the formatting spans matched by p. A match consumes
+// the boundary after its span, which may be the boundary before the next one
+// as in "*a* *b*", so a second pass converts the spans the first one skipped.
+func replaceSpans(p *regexp.Regexp, text, repl string) string {
+	for i := 0; i < 2; i++ {
+		text = p.ReplaceAllString(text, repl)
+	}
+	return text
+}
+
+// renderControl renders the inside of one <...> control sequence.
+func (c *Client) renderControl(ctx context.Context, seq string) string {
+	target, label, hasLabel := strings.Cut(seq, "|")
+	label = html.UnescapeString(label)
+
+	switch {
+	case strings.HasPrefix(target, "@"):
+		return "@" + c.userName(ctx, target[1:])
+	case strings.HasPrefix(target, "#"):
+		if hasLabel && label != "" {
+			return "#" + label
+		}
+		return "#" + c.channelName(ctx, target[1:])
+	case strings.HasPrefix(target, "!subteam^"):
+		if hasLabel && label != "" {
+			return label
+		}
+		return "@" + c.userGroupHandle(ctx, strings.TrimPrefix(target, "!subteam^"))
+	case strings.HasPrefix(target, "!"):
+		if hasLabel && label != "" {
+			// e.g. <!date^1392734382^{date}|Feb 18, 2014>
+			return label
+		}
+		command, _, _ := strings.Cut(target[1:], "^")
+		return "@" + command
+	}
+
+	url := html.UnescapeString(target)
+	if !hasLabel || label == "" || label == url {
+		return url
+	}
+	if "mailto:"+label == url {
+		return label
+	}
+	return fmt.Sprintf("[%s](%s)", label, url)
+}
@@ -0,0 +1,51 @@
+package slack
+
+import (
+	"context"
+	"testing"
+
+	"github.com/slack-go/slack"
+)
+
+func TestRenderMrkdwn(t *testing.T) {
+	api := &Client{dir: newDirectory()}
+	ana := &slack.User{ID: "U1"}
+	ana.Profile.DisplayName = "ana"
+	api.dir.users["U1"] = ana
+	api.dir.channels["C1"] = "general"
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"bold", "*a*", "**a**"},
+		{"adjacent bold", "*a* *b* *c*", "**a** **b** **c**"},
+		{"adjacent strike", "~a~ ~b~", "~~a~~ ~~b~~"},
+		{"bold in sentence", "it is *done*.", "it is **done**."},
+		{"strike in bold", "*bold ~gone~*", "**bold ~~gone~~**"},
+		{"bold in strike", "~*a*~", "~~**a**~~"},
+		{"bold in italics", "_*a*_", "_**a**_"},
+		{"already doubled", "**a**", "**a**"},
+		{"not a span", "2*3*4", "2*3*4"},
+		{"code is left alone", "`*a*` and ```*b* &amp;```", "`*a*` and ```*b* &```"},
+		{"user mention", "ask <@U1>", "ask @ana"},
+		{"channel mention", "in <#C1>", "in #general"},
+		{"labelled channel", "in <#C2|random>", "in #random"},
+		{"special mention", "<!here> look", "@here look"},
+		{"link", "<https://example.com>", "https://example.com"},
+		{"labelled link", "<https://example.com/?a=1&amp;b=2|A &amp; B>", "[A & B](https://example.com/?a=1&b=2)"},
+		{"mail link", "<mailto:ana@example.com|ana@example.com>", "ana@example.com"},
+		{"marker in link", "<https://example.com/*a*|x>", "[x](https://example.com/*a*)"},
+		{"entities", "a &lt; b &amp;&amp; c &gt; d", "a < b && c > d"},
+		{"entities unescaped once", "&amp;lt;", "&lt;"},
+		{"label unescaped once", "<https://example.com|&amp;lt;>", "[&lt;](https://example.com)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := api.renderMrkdwn(context.Background(), tt.in); got != tt.want {
+				t.Errorf("renderMrkdwn(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package slack
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/slack-go/slack"
+)
+
+const (
+	// expertSearchSize is how many Slack matches are looked at to find the
+	// people who know about a topic.
+	expertSearchSize = 100
+	// maxContributorLinks bounds the messages linked per contributor.
+	maxContributorLinks = 3
+)
+
+// Profile is what Slack tells about a person.
+type Profile struct {
+	SlackID  string `json:"slack_id,omitempty"`
+	Name     string `json:"name"`
+	RealName string `json:"real_name,omitempty"`
+	Title    string `json:"title,omitempty"`
+	Email    string `json:"email,omitempty"`
+	Timezone string `json:"timezone,omitempty"`
+	Status   string `json:"status,omitempty"`
+}
+
+// Contributor is someone who has written about a topic in Slack.
+type Contributor struct {
+	Profile
+	// Messages counts their matching messages, and Score weighs each by its
+	// rank in the search.
+	Messages int
+	Score    float64
+	// Links links to some of those messages.
+	Links []string
+}
+
+func (c *Client) GetUserByEmail(ctx context.Context, email string) (*slack.User, error) {
+	return call(ctx, c, "users.lookupByEmail", func(ctx context.Context) (*slack.User, error) {
+		return c.Client.GetUserByEmailContext(ctx, email)
+	})
+}
+
+// TopicContributors searches the workspaces of request for topic and returns
+// who wrote the matching messages, best ranked first. The same person is
+// merged across workspaces by their email. A workspace that can't be searched
+// is skipped, unless it is the only one; then the result to return instead is
+// given.
+func TopicContributors(ctx context.Context, request mcp.CallToolRequest, topic string) ([]Contributor, *mcp.CallToolResult) {
+	clients, errResult := clientsForRequest(request)
+	if errResult != nil {
+		return nil, errResult
+	}
+
+	byKey := map[string]*Contributor{}
+	var order []string
+	for _, api := range clients {
+		var result searchResult
+		var err error
+		if api.cfg.BotToken() {
+			if err := checkBotSearch(api.cfg, searchFilters{}); err != nil {
+				if len(clients) == 1 {
+					return nil, mcp.NewToolResultError(err.Error())
+				}
+				log.Printf("Unable to search Slack workspace %s: %v", api.Workspace, err)
+				continue
+			}
+			result.Matches = indexMatches(ctx, api, topic, searchFilters{}, expertSearchSize, nil)
+		} else {
+			result, err = searchMessages(ctx, api, topic, slack.SearchParameters{Sort: "score", SortDirection: "desc"}, expertSearchSize, 1)
+			if err != nil && len(result.Matches) == 0 {
+				if len(clients) == 1 {
+					return nil, toolError("search Slack", err)
+				}
+				log.Printf("Unable to search Slack workspace %s: %v", api.Workspace, err)
+				continue
+			}
+		}
+		for rank, m := range result.Matches {
+			u := api.user(ctx, m.User)
+			if u == nil || u.IsBot || u.Deleted {
+				continue
+			}
+			key := personKey(u)
+			c, ok := byKey[key]
+			if !ok {
+				c = &Contributor{}
+				byKey[key] = c
+				order = append(order, key)
+			}
+			c.Profile = newProfile(u)
+			c.Messages++
+			// Better ranked matches say more about what the person knows.
+			c.Score += 1 - float64(rank)/float64(len(result.Matches)+1)
+			if len(c.Links) < maxContributorLinks && m.Permalink != "" {
+				c.Links = append(c.Links, m.Permalink)
+			}
+		}
+	}
+
+	contributors := make([]Contributor, 0, len(order))
+	for _, key := range order {
+		contributors = append(contributors, *byKey[key])
+	}
+	return contributors, nil
+}
+
+// ProfileByEmail returns the Slack profile of the person with the given email
+// in the first workspace of request that knows them.
+func ProfileByEmail(ctx context.Context, request mcp.CallToolRequest, email string) (Profile, bool) {
+	clients, errResult := clientsForRequest(request)
+	if errResult != nil {
+		return Profile{}, false
+	}
+	for _, api := range clients {
+		if u, err := api.GetUserByEmail(ctx, email); err == nil {
+			return newProfile(u), true
+		}
+	}
+	return Profile{}, false
+}
+
+// personKey identifies a person across Slack and Drive by their email, or by
+// their Slack ID when the token can't see emails.
+func personKey(u *slack.User) string {
+	if u.Profile.Email != "" {
+		return strings.ToLower(u.Profile.Email)
+	}
+	return u.ID
+}
+
+func newProfile(u *slack.User) Profile {
+	p := Profile{
+		SlackID:  u.ID,
+		Name:     u.Profile.DisplayName,
+		RealName: u.RealName,
+		Title:    u.Profile.Title,
+		Email:    u.Profile.Email,
+		Timezone: u.TZLabel,
+		Status:   strings.TrimSpace(u.Profile.StatusEmoji + " " + u.Profile.StatusText),
+	}
+	if p.Name == "" {
+		p.Name = u.RealName
+	}
+	if p.Name == "" {
+		p.Name = u.Name
+	}
+	if p.Timezone == "" {
+		p.Timezone = u.TZ
+	}
+	return p
+}
@@ -0,0 +1,109 @@
+package slack
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/slack-go/slack"
+)
+
+var (
+	// archivesPattern matches https://team.slack.com/archives/C123/p1712345678123456.
+	archivesPattern = regexp.MustCompile(`^/archives/([A-Z0-9]+)/p(\d{10})(\d{6})/?$`)
+	// clientThreadPattern matches https://app.slack.com/client/T123/C123/thread/C123-1712345678.123456.
+	clientThreadPattern = regexp.MustCompile(`^/client/([A-Z0-9]+)/([A-Z0-9]+)/thread/([A-Z0-9]+)-(\d{10}\.\d{6})/?$`)
+)
+
+// permalinkTarget is what a Slack permalink points to.
+type permalinkTarget struct {
+	ChannelID string
+	// Timestamp is the linked message.
+	Timestamp string
+	// ThreadTs is the root of the thread the message belongs to, if the link
+	// says so.
+	ThreadTs string
+	// TeamID is the workspace of web client links.
+	TeamID string
+	// Base is the URL of the workspace the link was copied from.
+	Base string
+}
+
+// parsePermalink understands message permalinks, with or without the
+// ?thread_ts= of replies, and the thread links of the Slack web client. Links
+// may come in Slack's <url|label> markup, as copied from a message. The
+// channel in the path of a permalink is the one the message is in; its ?cid=
+// is ignored.
+func parsePermalink(link string) (permalinkTarget, error) {
+	var t permalinkTarget
+	target, _, _ := strings.Cut(strings.TrimPrefix(strings.TrimSpace(link), "<"), "|")
+	u, err := url.Parse(strings.TrimSuffix(target, ">"))
+	if err != nil || (u.Hostname() != "slack.com" && !strings.HasSuffix(u.Hostname(), ".slack.com")) {
+		return t, fmt.Errorf("%q is not a Slack link", link)
+	}
+	t.Base = u.Scheme + "://" + u.Host + "/"
+
+	if m := archivesPattern.FindStringSubmatch(u.Path); m != nil {
+		t.ChannelID = m[1]
+		t.Timestamp = m[2] + "." + m[3]
+		t.ThreadTs = u.Query().Get("thread_ts")
+		return t, nil
+	}
+	if m := clientThreadPattern.FindStringSubmatch(u.Path); m != nil {
+		t.TeamID = m[1]
+		t.ChannelID = m[3]
+		t.Timestamp = m[4]
+		t.ThreadTs = m[4]
+		return t, nil
+	}
+	return t, fmt.Errorf("%q is not a link to a Slack message or thread", link)
+}
+
+func GetThreadFromSlack(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	link, _ := request.Params.Arguments["permalink"].(string)
+	target, err := parsePermalink(link)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	api, errResult := clientForLink(ctx, request, target)
+	if errResult != nil {
+		return errResult, nil
+	}
+
+	// conversations.replies returns the whole thread for the root or any
+	// reply, and just the message itself when it isn't threaded.
+	ts := target.ThreadTs
+	if ts == "" {
+		ts = target.Timestamp
+	}
+	messages, err := fetchThreadMessages(ctx, api, target.ChannelID, ts)
+	if err != nil {
+		return toolError("read the Slack thread", err), nil
+	}
+	if len(messages) == 0 {
+		return mcp.NewToolResultError("The linked Slack message could not be found."), nil
+	}
+
+	rootTs := messages[0].Timestamp
+	base := target.Base
+	if base == "" || strings.HasPrefix(base, "https://app.slack.com") {
+		base = api.workspaceURL(ctx)
+	}
+	g := &threadGroup{
+		Key: threadKey{ChannelID: target.ChannelID, ThreadTs: rootTs},
+		Matches: []slack.SearchMessage{{
+			Channel:   slack.CtxChannel{ID: target.ChannelID, Name: api.channelName(ctx, target.ChannelID)},
+			Timestamp: target.Timestamp,
+			Permalink: messagePermalink(base, target.ChannelID, rootTs, ""),
+		}},
+	}
+	thread := newThread(ctx, api, g, messages)
+	attachFileContents(ctx, []Thread{thread})
+
+	responseText := fmt.Sprintf("Here is the Slack thread the user linked. The linked message is marked as matched in the structured result.\n\n%s", renderThreads([]Thread{thread}))
+	return threadsResult(responseText, []Thread{thread}, link), nil
+}
@@ -0,0 +1,65 @@
+package slack
+
+import "testing"
+
+func TestParsePermalink(t *testing.T) {
+	tests := []struct {
+		name    string
+		link    string
+		want    permalinkTarget
+		wantErr bool
+	}{
+		{
+			name: "plain link",
+			link: "https://acme.slack.com/archives/C123ABC/p1712345678123456",
+			want: permalinkTarget{ChannelID: "C123ABC", Timestamp: "1712345678.123456", Base: "https://acme.slack.com/"},
+		},
+		{
+			name: "link markup",
+			link: " <https://acme.slack.com/archives/C123ABC/p1712345678123456|see this> ",
+			want: permalinkTarget{ChannelID: "C123ABC", Timestamp: "1712345678.123456", Base: "https://acme.slack.com/"},
+		},
+		{
+			name: "link markup without label",
+			link: "<https://acme.slack.com/archives/C123ABC/p1712345678123456>",
+			want: permalinkTarget{ChannelID: "C123ABC", Timestamp: "1712345678.123456", Base: "https://acme.slack.com/"},
+		},
+		{
+			name: "reply",
+			link: "https://acme.slack.com/archives/C123ABC/p1712345678123456?thread_ts=1712345600.000100&cid=C999XYZ",
+			want: permalinkTarget{ChannelID: "C123ABC", Timestamp: "1712345678.123456", ThreadTs: "1712345600.000100", Base: "https://acme.slack.com/"},
+		},
+		{
+			name: "web client thread",
+			link: "https://app.slack.com/client/T123ABC/C123ABC/thread/C123ABC-1712345678.123456",
+			want: permalinkTarget{ChannelID: "C123ABC", Timestamp: "1712345678.123456", ThreadTs: "1712345678.123456", TeamID: "T123ABC", Base: "https://app.slack.com/"},
+		},
+		{
+			name:    "other host",
+			link:    "https://acme.slack.com.example.org/archives/C123ABC/p1712345678123456",
+			wantErr: true,
+		},
+		{
+			name:    "not a message",
+			link:    "https://acme.slack.com/archives/C123ABC",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parsePermalink(tt.link)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parsePermalink(%q) = %+v, want an error", tt.link, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parsePermalink(%q): %v", tt.link, err)
+			}
+			if got != tt.want {
+				t.Errorf("parsePermalink(%q) = %+v, want %+v", tt.link, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package slack
+
+import (
+	"math"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Weights of the relevance signals combined by rankByRelevance.
+const (
+	searchWeight     = 0.35
+	bm25Weight       = 0.35
+	recencyWeight    = 0.15
+	engagementWeight = 0.15
+)
+
+// curatedBoost multiplies the score of threads holding a pinned or bookmarked
+// message.
+const curatedBoost = 1.5
+
+// BM25 parameters.
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true,
+	"by": true, "for": true, "from": true, "how": true, "in": true, "is": true, "it": true,
+	"of": true, "on": true, "or": true, "the": true, "to": true, "what": true, "with": true,
+}
+
+// tokenize lowercases text and splits it into words, dropping stop words.
+func tokenize(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	tokens := words[:0]
+	for _, w := range words {
+		if !stopWords[w] {
+			tokens = append(tokens, w)
+		}
+	}
+	return tokens
+}
+
+// bm25Scores scores every document against the query terms with Okapi BM25.
+func bm25Scores(query []string, docs [][]string) []float64 {
+	scores := make([]float64, len(docs))
+	if len(docs) == 0 || len(query) == 0 {
+		return scores
+	}
+
+	var totalLen int
+	docFreq := map[string]int{}
+	termFreqs := make([]map[string]int, len(docs))
+	for i, doc := range docs {
+		totalLen += len(doc)
+		termFreqs[i] = map[string]int{}
+		for _, t := range doc {
+			termFreqs[i][t]++
+		}
+		for t := range termFreqs[i] {
+			docFreq[t]++
+		}
+	}
+	avgLen := float64(totalLen) / float64(len(docs))
+	if avgLen == 0 {
+		return scores
+	}
+
+	n := float64(len(docs))
+	for i, doc := range docs {
+		for _, q := range query {
+			tf := float64(termFreqs[i][q])
+			if tf == 0 {
+				continue
+			}
+			df := float64(docFreq[q])
+			idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+			scores[i] += idf * tf * (bm25K1 + 1) / (tf + bm25K1*(1-bm25B+bm25B*float64(len(doc))/avgLen))
+		}
+	}
+	return scores
+}
+
+// recencyScore decays from 1 for a conversation active now to 0.5 after
+// halfLifeDays.
+func recencyScore(t Thread, now time.Time, halfLifeDays float64) float64 {
+	var latest time.Time
+	for _, m := range t.Messages {
+		if ts, ok := parseTimestamp(m.Timestamp); ok && ts.After(latest) {
+			latest = ts
+		}
+	}
+	if latest.IsZero() {
+		return 0
+	}
+	ageDays := max(now.Sub(latest).Hours()/24, 0)
+	return math.Exp(-math.Ln2 * ageDays / halfLifeDays)
+}
+
+// engagement counts the replies and reactions of a conversation.
+func engagement(t Thread) float64 {
+	n := len(t.Messages) - 1
+	for _, m := range t.Messages {
+		n += m.Reactions
+	}
+	return math.Log1p(float64(max(n, 0)))
+}
+
+// normalize scales values into [0, 1] by their maximum.
+func normalize(values []float64) {
+	var top float64
+	for _, v := range values {
+		top = max(top, v)
+	}
+	if top == 0 {
+		return
+	}
+	for i := range values {
+		values[i] /= top
+	}
+}
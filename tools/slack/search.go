@@ -0,0 +1,165 @@
+package slack
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/slack-go/slack"
+)
+
+// searchPageSize is the most matches search.messages returns per call.
+const searchPageSize = 100
+
+type searchResult struct {
+	Matches []slack.SearchMessage
+	Total   int
+	// NextPage is the page to ask for to continue, or 0 if there is none.
+	NextPage int
+}
+
+// searchMessages returns page number page (1-based) of the matches for query,
+// where every page holds limit matches. Pages bigger than what Slack returns
+// in one call are assembled from several calls. On error the matches fetched
+// so far are returned along with it.
+func searchMessages(ctx context.Context, api *Client, query string, params slack.SearchParameters, limit, page int) (searchResult, error) {
+	var res searchResult
+
+	offset := (page - 1) * limit
+	params.Count = min(limit, searchPageSize)
+	params.Page = offset/params.Count + 1
+	skip := offset % params.Count
+
+	for len(res.Matches) < limit {
+		result, err := api.SearchMessages(ctx, query, params)
+		if err != nil {
+			return res, err
+		}
+		res.Total = result.Total
+
+		matches := result.Matches
+		if skip > 0 {
+			matches = matches[min(skip, len(matches)):]
+			skip = 0
+		}
+		res.Matches = append(res.Matches, matches[:min(len(matches), limit-len(res.Matches))]...)
+
+		if result.Paging.Page >= result.Paging.Pages || len(result.Matches) == 0 {
+			break
+		}
+		params.Page++
+	}
+
+	if offset+len(res.Matches) < res.Total && len(res.Matches) == limit {
+		res.NextPage = page + 1
+	}
+	return res, nil
+}
+
+// intArgument reads an optional numeric tool argument. JSON numbers arrive as
+// float64.
+func intArgument(request mcp.CallToolRequest, name string, def int) int {
+	if v, ok := request.Params.Arguments[name].(float64); ok {
+		return int(v)
+	}
+	return def
+}
+
+// searchFilters are the search.messages modifiers exposed as tool arguments.
+type searchFilters struct {
+	Channels      []string
+	Authors       []string
+	After         string
+	Before        string
+	HasLink       bool
+	HasAttachment bool
+	ThreadOnly    bool
+}
+
+// Channel and user names may not contain anything that would end the modifier
+// and start another one.
+var searchNamePattern = regexp.MustCompile(`^[\p{L}\p{N}._-]+$`)
+
+const searchDateLayout = "2006-01-02"
+
+func searchFiltersFromRequest(request mcp.CallToolRequest) (searchFilters, error) {
+	f := searchFilters{
+		Channels:      stringsArgument(request, "channels"),
+		Authors:       stringsArgument(request, "authors"),
+		HasLink:       boolArgument(request, "has_link"),
+		HasAttachment: boolArgument(request, "has_attachment"),
+		ThreadOnly:    boolArgument(request, "thread_only"),
+	}
+	f.After, _ = request.Params.Arguments["after"].(string)
+	f.Before, _ = request.Params.Arguments["before"].(string)
+
+	for i, c := range f.Channels {
+		c = strings.TrimPrefix(strings.TrimSpace(c), "#")
+		if !searchNamePattern.MatchString(c) {
+			return f, fmt.Errorf("invalid channel name %q", c)
+		}
+		f.Channels[i] = c
+	}
+	for i, a := range f.Authors {
+		a = strings.TrimPrefix(strings.TrimSpace(a), "@")
+		if !searchNamePattern.MatchString(a) {
+			return f, fmt.Errorf("invalid user name %q", a)
+		}
+		f.Authors[i] = a
+	}
+	for _, d := range []string{f.After, f.Before} {
+		if d == "" {
+			continue
+		}
+		if _, err := time.Parse(searchDateLayout, d); err != nil {
+			return f, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", d)
+		}
+	}
+	return f, nil
+}
+
+// buildSearchQuery appends the filters to topic as Slack search modifiers.
+func buildSearchQuery(topic string, f searchFilters) string {
+	parts := []string{strings.TrimSpace(topic)}
+	for _, c := range f.Channels {
+		parts = append(parts, "in:#"+c)
+	}
+	for _, a := range f.Authors {
+		parts = append(parts, "from:@"+a)
+	}
+	if f.After != "" {
+		parts = append(parts, "after:"+f.After)
+	}
+	if f.Before != "" {
+		parts = append(parts, "before:"+f.Before)
+	}
+	if f.HasLink {
+		parts = append(parts, "has:link")
+	}
+	if f.HasAttachment {
+		parts = append(parts, "has:file")
+	}
+	if f.ThreadOnly {
+		parts = append(parts, "is:thread")
+	}
+	return strings.Join(parts, " ")
+}
+
+func stringsArgument(request mcp.CallToolRequest, name string) []string {
+	values, _ := request.Params.Arguments[name].([]interface{})
+	var out []string
+	for _, v := range values {
+		if s, ok := v.(string); ok && s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func boolArgument(request mcp.CallToolRequest, name string) bool {
+	v, _ := request.Params.Arguments[name].(bool)
+	return v
+}
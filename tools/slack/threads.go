@@ -0,0 +1,131 @@
+package slack
+
+import (
+	"context"
+	"net/url"
+	"sync"
+
+	"github.com/slack-go/slack"
+)
+
+const (
+	// maxThreadFetches bounds how many threads are expanded at the same time.
+	maxThreadFetches = 4
+	// repliesPageSize is how many messages are asked for per
+	// conversations.replies call.
+	repliesPageSize = 200
+	// maxThreadMessages bounds how many messages of one thread are fetched.
+	maxThreadMessages = 1000
+)
+
+// threadKey identifies a conversation: a thread by its root timestamp, or a
+// standalone message by its own timestamp.
+type threadKey struct {
+	ChannelID string
+	ThreadTs  string
+}
+
+// matchThreadKey returns the key of the thread a search match belongs to.
+// Slack adds thread_ts to the permalink of replies; roots and standalone
+// messages are keyed by their own timestamp.
+func matchThreadKey(match slack.SearchMessage) threadKey {
+	ts := threadTimestampFromPermalink(match.Permalink)
+	if ts == "" {
+		ts = match.Timestamp
+	}
+	return threadKey{ChannelID: match.Channel.ID, ThreadTs: ts}
+}
+
+func threadTimestampFromPermalink(permalink string) string {
+	u, err := url.Parse(permalink)
+	if err != nil {
+		return ""
+	}
+	return u.Query().Get("thread_ts")
+}
+
+// threadGroup is a conversation together with the matches found in it.
+type threadGroup struct {
+	Key     threadKey
+	Matches []slack.SearchMessage
+	// SearchRank is the index of the group's first match in the search
+	// results.
+	SearchRank int
+
+	Messages []slack.Message
+	Err      error
+}
+
+// representative returns the match to resolve the conversation from,
+// preferring the thread root when it matched too.
+func (g *threadGroup) representative() slack.SearchMessage {
+	for _, m := range g.Matches {
+		if m.Timestamp == g.Key.ThreadTs {
+			return m
+		}
+	}
+	return g.Matches[0]
+}
+
+// groupMatchesByThread groups matches by conversation, keeping the order in
+// which conversations first appear.
+func groupMatchesByThread(matches []slack.SearchMessage) []*threadGroup {
+	byKey := map[threadKey]*threadGroup{}
+	var groups []*threadGroup
+	for i, m := range matches {
+		key := matchThreadKey(m)
+		g, ok := byKey[key]
+		if !ok {
+			g = &threadGroup{Key: key, SearchRank: i}
+			byKey[key] = g
+			groups = append(groups, g)
+		}
+		g.Matches = append(g.Matches, m)
+	}
+	return groups
+}
+
+// expandThreads fetches the conversation of every group concurrently, at most
+// maxThreadFetches at a time, using fetch. Groups may repeat a conversation
+// when several workspaces or the digest feed the same list, so each
+// conversation is fetched once and shared by the groups keyed by it.
+func expandThreads(ctx context.Context, groups []*threadGroup, fetch func(context.Context, *threadGroup) ([]slack.Message, error)) {
+	first := map[threadKey]*threadGroup{}
+	var unique, repeated []*threadGroup
+	for _, g := range groups {
+		if _, ok := first[g.Key]; ok {
+			repeated = append(repeated, g)
+			continue
+		}
+		first[g.Key] = g
+		unique = append(unique, g)
+	}
+
+	sem := make(chan struct{}, maxThreadFetches)
+	var wg sync.WaitGroup
+	for _, g := range unique {
+		wg.Add(1)
+		go func(g *threadGroup) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				g.Err = ctx.Err()
+				return
+			}
+			g.Messages, g.Err = fetch(ctx, g)
+		}(g)
+	}
+	wg.Wait()
+
+	for _, g := range repeated {
+		g.Messages, g.Err = first[g.Key].Messages, first[g.Key].Err
+	}
+}
+
+// fetchMatchConversation resolves the conversation of a group of search
+// matches.
+func fetchMatchConversation(ctx context.Context, api *Client, g *threadGroup) ([]slack.Message, error) {
+	return GetFullConversationForMatch(ctx, api, g.representative())
+}
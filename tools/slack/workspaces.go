@@ -0,0 +1,109 @@
+package slack
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/pranavbalakrishnan4100/beacon-mcp-server/config"
+)
+
+// workspaceNames returns the workspace named by the workspace argument of
+// request, or every configured workspace when it is empty. A nil result
+// carries the tool error to return.
+func workspaceNames(request mcp.CallToolRequest) ([]string, *mcp.CallToolResult) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, mcp.NewToolResultError(fmt.Sprintf("Unable to load config: %v", err))
+	}
+	names := cfg.SlackWorkspaceNames()
+	workspace, _ := request.Params.Arguments["workspace"].(string)
+	if workspace == "" {
+		return names, nil
+	}
+	if _, err := cfg.SlackWorkspace(workspace); err != nil {
+		return nil, mcp.NewToolResultError(fmt.Sprintf("Unknown Slack workspace %q. Available workspaces: %s", workspace, strings.Join(names, ", ")))
+	}
+	return []string{workspace}, nil
+}
+
+// clientsForRequest returns the clients of the workspaces workspaceNames
+// selects. Workspaces that can't be connected to are left out, unless none
+// can.
+func clientsForRequest(request mcp.CallToolRequest) ([]*Client, *mcp.CallToolResult) {
+	names, errResult := workspaceNames(request)
+	if errResult != nil {
+		return nil, errResult
+	}
+	var clients []*Client
+	var firstErr error
+	for _, name := range names {
+		c, err := newClient(name)
+		if err != nil {
+			log.Printf("Slack workspace %s: %v", name, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		clients = append(clients, c)
+	}
+	if len(clients) == 0 {
+		return nil, toolError("connect to Slack", firstErr)
+	}
+	return clients, nil
+}
+
+// clientForRequest returns the client of the workspace named by the
+// workspace argument of request, or of the default workspace when it is
+// empty. Without a default workspace the argument is required, unless only
+// one workspace is configured.
+func clientForRequest(request mcp.CallToolRequest) (*Client, *mcp.CallToolResult) {
+	names, errResult := workspaceNames(request)
+	if errResult != nil {
+		return nil, errResult
+	}
+	if len(names) > 1 && names[0] != config.DefaultAccount {
+		return nil, mcp.NewToolResultError(fmt.Sprintf("No default Slack workspace is configured. Call the tool again with the workspace argument set to one of: %s", strings.Join(names, ", ")))
+	}
+	api, err := newClient(names[0])
+	if err != nil {
+		return nil, toolError("connect to Slack", err)
+	}
+	return api, nil
+}
+
+// clientForLink returns the client of the workspace a Slack link points into:
+// the one named by the workspace argument of request, else the one whose URL
+// or team ID the link carries. A link no workspace claims is refused rather
+// than acted on in the wrong workspace, unless only one is configured.
+func clientForLink(ctx context.Context, request mcp.CallToolRequest, target permalinkTarget) (*Client, *mcp.CallToolResult) {
+	if workspace, _ := request.Params.Arguments["workspace"].(string); workspace != "" {
+		return clientForRequest(request)
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, mcp.NewToolResultError(fmt.Sprintf("Unable to load config: %v", err))
+	}
+	names := cfg.SlackWorkspaceNames()
+	if len(names) == 1 {
+		api, err := newClient(names[0])
+		if err != nil {
+			return nil, toolError("connect to Slack", err)
+		}
+		return api, nil
+	}
+
+	for _, name := range names {
+		api, err := newClient(name)
+		if err != nil {
+			continue
+		}
+		if (target.TeamID != "" && target.TeamID == api.cfg.TeamID) || (target.Base != "" && target.Base == api.workspaceURL(ctx)) {
+			return api, nil
+		}
+	}
+	return nil, mcp.NewToolResultError(fmt.Sprintf("The link doesn't belong to any configured Slack workspace that could be identified. Call the tool again with the workspace argument set to one of: %s", strings.Join(names, ", ")))
+}
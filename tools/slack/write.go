@@ -0,0 +1,288 @@
+package slack
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/pranavbalakrishnan4100/beacon-mcp-server/config"
+	"github.com/slack-go/slack"
+)
+
+// maxScheduleAhead is how far in the future chat.scheduleMessage accepts a
+// message.
+const maxScheduleAhead = 120 * 24 * time.Hour
+
+// previewTTL is how long the preview token of a write tool can be used to
+// confirm the action.
+const previewTTL = 15 * time.Minute
+
+// preview is an action shown to the user and waiting for their approval.
+type preview struct {
+	key   string
+	shown time.Time
+}
+
+var (
+	previewsMu sync.Mutex
+	// previews is keyed by preview token.
+	previews = map[string]preview{}
+)
+
+// postedMessage describes a message Beacon posted or scheduled.
+type postedMessage struct {
+	ChannelID string `json:"channel_id"`
+	Channel   string `json:"channel"`
+	Timestamp string `json:"ts,omitempty"`
+	ThreadTs  string `json:"thread_ts,omitempty"`
+	Permalink string `json:"permalink,omitempty"`
+	// ScheduledMessageID and PostAt are only set for scheduled messages.
+	ScheduledMessageID string `json:"scheduled_message_id,omitempty"`
+	PostAt             string `json:"post_at,omitempty"`
+}
+
+func (c *Client) PostMessage(ctx context.Context, channelID string, options ...slack.MsgOption) (string, error) {
+	return call(ctx, c, "chat.postMessage", func(ctx context.Context) (string, error) {
+		_, ts, err := c.Client.PostMessageContext(ctx, channelID, options...)
+		return ts, err
+	})
+}
+
+func (c *Client) ScheduleMessage(ctx context.Context, channelID string, postAt time.Time, options ...slack.MsgOption) (string, error) {
+	return call(ctx, c, "chat.scheduleMessage", func(ctx context.Context) (string, error) {
+		_, id, err := c.Client.ScheduleMessageContext(ctx, channelID, strconv.FormatInt(postAt.Unix(), 10), options...)
+		return id, err
+	})
+}
+
+func (c *Client) AddReaction(ctx context.Context, name string, item slack.ItemRef) error {
+	_, err := call(ctx, c, "reactions.add", func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, c.Client.AddReactionContext(ctx, name, item)
+	})
+	return err
+}
+
+// writeTarget is where a write tool is about to act.
+type writeTarget struct {
+	api       *Client
+	channelID string
+	channel   string
+}
+
+// channelWriteTarget resolves the channel argument of a write tool and checks
+// it against the allowlist. A nil result carries the tool error to return.
+func channelWriteTarget(ctx context.Context, request mcp.CallToolRequest) (*writeTarget, *mcp.CallToolResult) {
+	channel, _ := request.Params.Arguments["channel"].(string)
+	if strings.TrimSpace(channel) == "" {
+		return nil, mcp.NewToolResultError("A channel is required.")
+	}
+	api, errResult := clientForRequest(request)
+	if errResult != nil {
+		return nil, errResult
+	}
+	t := &writeTarget{api: api}
+	id, name, err := t.api.resolveChannel(ctx, channel)
+	if err != nil {
+		return nil, toolError("find the Slack channel", err)
+	}
+	t.channelID, t.channel = id, name
+	return t, t.checkAllowed()
+}
+
+// messageWriteTarget is channelWriteTarget for tools that act on the message a
+// permalink points to.
+func messageWriteTarget(ctx context.Context, request mcp.CallToolRequest) (*writeTarget, permalinkTarget, *mcp.CallToolResult) {
+	link, _ := request.Params.Arguments["permalink"].(string)
+	msg, err := parsePermalink(link)
+	if err != nil {
+		return nil, msg, mcp.NewToolResultError(err.Error())
+	}
+	api, errResult := clientForLink(ctx, request, msg)
+	if errResult != nil {
+		return nil, msg, errResult
+	}
+	t := &writeTarget{api: api}
+	t.channelID, t.channel = msg.ChannelID, t.api.channelName(ctx, msg.ChannelID)
+	return t, msg, t.checkAllowed()
+}
+
+func (t *writeTarget) checkAllowed() *mcp.CallToolResult {
+	if t.api.cfg.WriteAllowed(t.channelID, t.channel) {
+		return nil
+	}
+	setting := "slack.write_channels"
+	if t.api.Workspace != config.DefaultAccount {
+		setting = "slack_workspaces." + t.api.Workspace + ".write_channels"
+	}
+	return mcp.NewToolResultError(fmt.Sprintf("Beacon is not allowed to post in #%s. Tell the user it has to be added to %s in Beacon's config first.", t.channel, setting))
+}
+
+// confirmation returns the preview to show when the confirm argument isn't
+// set, or nil once the user has approved the action. The preview carries a
+// token that confirm has to come with, so only an action that was shown, in
+// the same words, can be carried out, and only once.
+func (t *writeTarget) confirmation(request mcp.CallToolRequest, action string) *mcp.CallToolResult {
+	// The action names the channel but not the workspace it is in.
+	key := t.api.Workspace + "\x00" + action
+
+	previewsMu.Lock()
+	defer previewsMu.Unlock()
+	for token, p := range previews {
+		if time.Since(p.shown) > previewTTL {
+			delete(previews, token)
+		}
+	}
+
+	if boolArgument(request, "confirm") {
+		token, _ := request.Params.Arguments["preview_token"].(string)
+		if p, ok := previews[token]; ok && p.key == key {
+			delete(previews, token)
+			return nil
+		}
+		return mcp.NewToolResultError("confirm=true needs the preview_token of the preview the user approved, for exactly the same action. Call the tool without confirm to get a new preview.")
+	}
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return toolError("prepare the preview", err)
+	}
+	token := hex.EncodeToString(b)
+	previews[token] = preview{key: key, shown: time.Now()}
+	return mcp.NewToolResultText(fmt.Sprintf("Nothing was sent yet. Beacon is about to %s. Show this to the user and call the tool again with confirm=true and preview_token=%q only once they have approved it.", action, token))
+}
+
+// quote formats text for a confirmation preview.
+func quote(text string) string {
+	return "\n\n> " + strings.ReplaceAll(text, "\n", "\n> ")
+}
+
+// postedResult reports a message that was posted or scheduled.
+func (t *writeTarget) postedResult(ctx context.Context, what string, m postedMessage) *mcp.CallToolResult {
+	m.ChannelID, m.Channel = t.channelID, t.channel
+	if m.Timestamp != "" {
+		m.Permalink = messagePermalink(t.api.workspaceURL(ctx), m.ChannelID, m.Timestamp, m.ThreadTs)
+	}
+	text := fmt.Sprintf("%s in #%s.", what, m.Channel)
+	if m.Permalink != "" {
+		text += " " + m.Permalink
+	}
+	return structuredResult(text, "slack://message", m)
+}
+
+func messageText(request mcp.CallToolRequest) (string, *mcp.CallToolResult) {
+	text, _ := request.Params.Arguments["text"].(string)
+	if strings.TrimSpace(text) == "" {
+		return "", mcp.NewToolResultError("The message text is empty.")
+	}
+	return text, nil
+}
+
+func PostMessageToSlack(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	text, errResult := messageText(request)
+	if errResult != nil {
+		return errResult, nil
+	}
+	t, errResult := channelWriteTarget(ctx, request)
+	if errResult != nil {
+		return errResult, nil
+	}
+	if preview := t.confirmation(request, fmt.Sprintf("post this message in #%s:%s", t.channel, quote(text))); preview != nil {
+		return preview, nil
+	}
+
+	ts, err := t.api.PostMessage(ctx, t.channelID, slack.MsgOptionText(text, false))
+	if err != nil {
+		return toolError("post the Slack message", err), nil
+	}
+	return t.postedResult(ctx, "Posted the message", postedMessage{Timestamp: ts}), nil
+}
+
+func ReplyInSlackThread(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	text, errResult := messageText(request)
+	if errResult != nil {
+		return errResult, nil
+	}
+	t, msg, errResult := messageWriteTarget(ctx, request)
+	if errResult != nil {
+		return errResult, nil
+	}
+
+	// Links to a reply don't always say which thread it is in, and replying
+	// to a reply has to go to the root of its thread.
+	threadTs := msg.ThreadTs
+	if threadTs == "" {
+		full, err := fetchMessageByTimestamp(ctx, t.api, msg.ChannelID, msg.Timestamp)
+		if err != nil {
+			return toolError("find the Slack thread", err), nil
+		}
+		if threadTs = conversationThreadTimestamp(*full); threadTs == "" {
+			threadTs = msg.Timestamp
+		}
+	}
+	if preview := t.confirmation(request, fmt.Sprintf("reply in the thread in #%s with:%s", t.channel, quote(text))); preview != nil {
+		return preview, nil
+	}
+
+	ts, err := t.api.PostMessage(ctx, t.channelID, slack.MsgOptionText(text, false), slack.MsgOptionTS(threadTs))
+	if err != nil {
+		return toolError("reply in the Slack thread", err), nil
+	}
+	return t.postedResult(ctx, "Replied in the thread", postedMessage{Timestamp: ts, ThreadTs: threadTs}), nil
+}
+
+func AddReactionInSlack(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	emoji, _ := request.Params.Arguments["emoji"].(string)
+	emoji = strings.Trim(strings.TrimSpace(emoji), ":")
+	if emoji == "" {
+		return mcp.NewToolResultError("An emoji name is required."), nil
+	}
+	t, msg, errResult := messageWriteTarget(ctx, request)
+	if errResult != nil {
+		return errResult, nil
+	}
+	if preview := t.confirmation(request, fmt.Sprintf("react with :%s: to the message in #%s", emoji, t.channel)); preview != nil {
+		return preview, nil
+	}
+
+	if err := t.api.AddReaction(ctx, emoji, slack.NewRefToMessage(msg.ChannelID, msg.Timestamp)); err != nil {
+		return toolError("add the Slack reaction", err), nil
+	}
+	return t.postedResult(ctx, fmt.Sprintf("Reacted with :%s:", emoji), postedMessage{Timestamp: msg.Timestamp, ThreadTs: msg.ThreadTs}), nil
+}
+
+func ScheduleMessageInSlack(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	text, errResult := messageText(request)
+	if errResult != nil {
+		return errResult, nil
+	}
+	postAtArg, _ := request.Params.Arguments["post_at"].(string)
+	postAt, err := time.Parse(time.RFC3339, strings.TrimSpace(postAtArg))
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid post_at %q, expected an RFC 3339 time such as 2025-01-31T09:00:00+01:00.", postAtArg)), nil
+	}
+	if until := time.Until(postAt); until <= 0 || until > maxScheduleAhead {
+		return mcp.NewToolResultError("post_at must be in the future and at most 120 days ahead."), nil
+	}
+	t, errResult := channelWriteTarget(ctx, request)
+	if errResult != nil {
+		return errResult, nil
+	}
+	if preview := t.confirmation(request, fmt.Sprintf("schedule this message in #%s for %s:%s", t.channel, postAt.Format(time.RFC1123), quote(text))); preview != nil {
+		return preview, nil
+	}
+
+	id, err := t.api.ScheduleMessage(ctx, t.channelID, postAt, slack.MsgOptionText(text, false))
+	if err != nil {
+		return toolError("schedule the Slack message", err), nil
+	}
+	return t.postedResult(ctx, "Scheduled the message for "+postAt.Format(time.RFC1123), postedMessage{
+		ScheduledMessageID: id,
+		PostAt:             postAt.Format(time.RFC3339),
+	}), nil
+}
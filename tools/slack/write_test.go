@@ -0,0 +1,79 @@
+package slack
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+var previewTokenPattern = regexp.MustCompile(`preview_token="([0-9a-f]+)"`)
+
+func writeRequest(args map[string]interface{}) mcp.CallToolRequest {
+	var r mcp.CallToolRequest
+	r.Params.Arguments = args
+	return r
+}
+
+// previewToken asks target for the preview of action and returns its token.
+func previewToken(t *testing.T, target *writeTarget, action string) string {
+	t.Helper()
+	result := target.confirmation(writeRequest(map[string]interface{}{}), action)
+	if result == nil || result.IsError {
+		t.Fatalf("confirmation without confirm = %+v, want a preview", result)
+	}
+	text := result.Content[0].(mcp.TextContent).Text
+	m := previewTokenPattern.FindStringSubmatch(text)
+	if m == nil {
+		t.Fatalf("preview %q has no token", text)
+	}
+	return m[1]
+}
+
+func confirm(target *writeTarget, action, token string) *mcp.CallToolResult {
+	args := map[string]interface{}{"confirm": true}
+	if token != "" {
+		args["preview_token"] = token
+	}
+	return target.confirmation(writeRequest(args), action)
+}
+
+func TestConfirmation(t *testing.T) {
+	target := &writeTarget{api: &Client{Workspace: "default"}, channelID: "C1", channel: "general"}
+	const action = "post this message in #general: hello"
+
+	t.Run("missing token", func(t *testing.T) {
+		previewToken(t, target, action)
+		if result := confirm(target, action, ""); result == nil || !result.IsError {
+			t.Errorf("confirm without a token = %+v, want an error", result)
+		}
+	})
+	t.Run("token for other text", func(t *testing.T) {
+		token := previewToken(t, target, action)
+		if result := confirm(target, "post this message in #general: goodbye", token); result == nil || !result.IsError {
+			t.Errorf("confirm with the token of another text = %+v, want an error", result)
+		}
+	})
+	t.Run("token for other workspace", func(t *testing.T) {
+		token := previewToken(t, target, action)
+		other := &writeTarget{api: &Client{Workspace: "sales"}, channelID: "C1", channel: "general"}
+		if result := confirm(other, action, token); result == nil || !result.IsError {
+			t.Errorf("confirm in another workspace = %+v, want an error", result)
+		}
+	})
+	t.Run("valid token", func(t *testing.T) {
+		token := previewToken(t, target, action)
+		if result := confirm(target, action, token); result != nil {
+			t.Errorf("confirm with the preview's token = %+v, want nil", result)
+		}
+	})
+	t.Run("reused token", func(t *testing.T) {
+		token := previewToken(t, target, action)
+		if result := confirm(target, action, token); result != nil {
+			t.Fatalf("first confirm = %+v, want nil", result)
+		}
+		if result := confirm(target, action, token); result == nil || !result.IsError {
+			t.Errorf("second confirm with the same token = %+v, want an error", result)
+		}
+	})
+}